@@ -0,0 +1,67 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AlertSpec defines an alert submitted through the Kubernetes API
+type AlertSpec struct {
+	// Labels of the alert. The "alertname" label selects the AlertReactions to run.
+	// +kubebuilder:validation:Required
+	Labels map[string]string `json:"labels"`
+
+	// Annotations of the alert
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Status of the alert. Changing it to resolved runs the onResolved actions of matching AlertReactions.
+	// +kubebuilder:validation:Enum=firing;resolved
+	// +kubebuilder:default=firing
+	Status string `json:"status,omitempty"`
+
+	// StartsAt is when the alert started firing
+	// If not specified, the creation time of the Alert is used
+	StartsAt *metav1.Time `json:"startsAt,omitempty"`
+}
+
+// AlertStatus defines the observed processing state of an Alert
+type AlertStatus struct {
+	// ObservedGeneration is the generation of the Alert that was last processed
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ProcessedAt indicates when the Alert was last processed
+	ProcessedAt *metav1.Time `json:"processedAt,omitempty"`
+
+	// MatchedReactions are the namespaced names of the AlertReactions that matched the alert
+	MatchedReactions []string `json:"matchedReactions,omitempty"`
+
+	// Jobs are the namespaced names of the jobs created for the alert
+	Jobs []string `json:"jobs,omitempty"`
+
+	// Errors that occurred while processing the alert
+	Errors []string `json:"errors,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Alert Name",type=string,JSONPath=`.spec.labels.alertname`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.spec.status`
+// +kubebuilder:printcolumn:name="Processed",type=date,JSONPath=`.status.processedAt`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Alert is the Schema for the alerts API. Creating or updating an Alert runs the matching
+// AlertReactions like an alert received through the webhook.
+type Alert struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AlertSpec   `json:"spec,omitempty"`
+	Status AlertStatus `json:"status,omitempty"`
+}
+
+// AlertList contains a list of Alert
+// +kubebuilder:object:root=true
+type AlertList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Alert `json:"items"`
+}
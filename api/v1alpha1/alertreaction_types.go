@@ -25,6 +25,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(GroupVersion,
 		&AlertReaction{},
 		&AlertReactionList{},
+		&AlertSource{},
+		&AlertSourceList{},
+		&Alert{},
+		&AlertList{},
 	)
 	metav1.AddToGroupVersion(scheme, GroupVersion)
 	return nil
@@ -68,24 +72,108 @@ const (
 // AlertReactionSpec defines the desired state of AlertReaction
 type AlertReactionSpec struct {
 	// AlertName specifies the Prometheus alert name to react to
-	// +kubebuilder:validation:Required
-	AlertName string `json:"alertName"`
+	// Optional if AlertNames or Matchers are specified. An AlertReaction without alert names reacts to
+	// alerts with any name that match its matchers, e.g. a matcher on "alertname" with the =~ operator.
+	AlertName string `json:"alertName,omitempty"`
+
+	// AlertNames specifies further alert names to react to, in addition to AlertName
+	AlertNames []string `json:"alertNames,omitempty"`
 
 	// Matchers defines additional conditions that must be met for the alert to trigger this reaction
 	// All matchers must match for the reaction to be triggered
-	// If no matchers are specified, only the AlertName is used for matching
+	// If no matchers are specified, only the alert names are used for matching
 	Matchers []AlertMatcher `json:"matchers,omitempty"`
 
+	// MatcherGroups defines alternative sets of matchers, like the matchers of Alertmanager routes
+	// If specified, all matchers of at least one group must match in addition to Matchers,
+	// e.g. [[severity=critical], [team=sre]] matches critical alerts or alerts of the sre team
+	MatcherGroups [][]AlertMatcher `json:"matcherGroups,omitempty"`
+
+	// Receivers restricts this reaction to alerts received on the given receivers
+	// The receiver is taken from the webhook path (/webhook/<receiver>) or, if not present, from the payload
+	// If no receivers are specified, alerts from all receivers are matched
+	Receivers []string `json:"receivers,omitempty"`
+
+	// Mode defines how often actions run for a webhook notification
+	// "alert" runs the actions once per matching alert
+	// "group" runs the actions once per notification group with all matching alerts, provided to the job
+	// as a JSON file
+	// +kubebuilder:default=alert
+	Mode ReactionMode `json:"mode,omitempty"`
+
+	// Query evaluates a PromQL expression against Prometheus on a schedule
+	// Each returned series is a firing alert named AlertName with the series labels, and resolves when it
+	// is no longer returned
+	Query *QueryTrigger `json:"query,omitempty"`
+
 	// Actions defines the list of actions to perform when the alert is received
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinItems=1
 	Actions []Action `json:"actions"`
 
+	// OnResolved defines actions to perform when a matching alert transitions to resolved
+	// Resolved alerts are matched with the same alertName and matchers as firing alerts
+	// If no onResolved actions are specified, resolved alerts are ignored by this reaction
+	OnResolved []Action `json:"onResolved,omitempty"`
+
 	// Volumes defines volumes that can be mounted by actions in this AlertReaction
 	// These volumes will be available to all jobs created by this AlertReaction
 	Volumes []Volume `json:"volumes,omitempty"`
 }
 
+// AllAlertNames returns AlertName and AlertNames without duplicates. It is empty if the
+// AlertReaction reacts to alerts with any name.
+func (s *AlertReactionSpec) AllAlertNames() []string {
+	var names []string
+	seen := make(map[string]bool, len(s.AlertNames)+1)
+	for _, name := range append([]string{s.AlertName}, s.AlertNames...) {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// MatchesAlertName checks if the AlertReaction reacts to alerts with the given name
+func (s *AlertReactionSpec) MatchesAlertName(alertName string) bool {
+	names := s.AllAlertNames()
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if name == alertName {
+			return true
+		}
+	}
+	return false
+}
+
+// ReactionMode defines how often an AlertReaction runs its actions
+// +kubebuilder:validation:Enum=alert;group
+type ReactionMode string
+
+const (
+	// ReactionModeAlert runs actions once per matching alert
+	ReactionModeAlert ReactionMode = "alert"
+	// ReactionModeGroup runs actions once per notification group
+	ReactionModeGroup ReactionMode = "group"
+)
+
+// QueryTrigger defines a PromQL expression evaluated on a schedule
+type QueryTrigger struct {
+	// Expr is the PromQL expression to evaluate, e.g. 'kube_deployment_status_replicas_unavailable > 0'
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Expr string `json:"expr"`
+
+	// Interval between evaluations of the expression
+	// +kubebuilder:default="1m"
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+	Interval string `json:"interval,omitempty"`
+}
+
 // Action defines a single action to perform when an alert is received
 type Action struct {
 	// Name of the action
@@ -116,8 +204,23 @@ type Action struct {
 	// ServiceAccount specifies the service account to use for the job created by this action
 	// If not specified, the default service account will be used
 	ServiceAccount string `json:"serviceAccount,omitempty"`
+
+	// CancelOnResolve defines what happens to jobs of this action that are still running
+	// when the alert that triggered them resolves
+	// "Delete" deletes the jobs and their pods
+	// If not specified, jobs keep running. Not supported in group mode.
+	CancelOnResolve CancelPolicy `json:"cancelOnResolve,omitempty"`
 }
 
+// CancelPolicy defines how running jobs are cancelled when their alert resolves
+// +kubebuilder:validation:Enum=Delete
+type CancelPolicy string
+
+const (
+	// CancelPolicyDelete deletes running jobs together with their pods
+	CancelPolicyDelete CancelPolicy = "Delete"
+)
+
 // EnvVar represents an environment variable present in a Container.
 type EnvVar struct {
 	// Name of the environment variable
@@ -277,8 +380,32 @@ type VolumeMount struct {
 	ReadOnly bool `json:"readOnly,omitempty"`
 }
 
+// Condition types reported in the status of AlertReactions
+const (
+	// ConditionReady is True when the AlertReaction is valid and all its references resolve
+	ConditionReady = "Ready"
+	// ConditionValid is False when the spec contains errors the CRD schema does not detect
+	ConditionValid = "Valid"
+	// ConditionReferencesResolved is False when referenced ConfigMaps, Secrets, ServiceAccounts or
+	// PersistentVolumeClaims do not exist
+	ConditionReferencesResolved = "ReferencesResolved"
+)
+
+// Annotations requesting manual runs of AlertReactions
+const (
+	// TriggerAnnotation requests a manual run of the actions of an AlertReaction when set to a new value
+	TriggerAnnotation = "karo.io/trigger"
+
+	// TriggeredByAnnotation is the user who last changed TriggerAnnotation. The admission webhook sets it
+	// from the authenticated user of the request and replaces any value sent by clients.
+	TriggeredByAnnotation = "karo.io/triggered-by"
+)
+
 // AlertReactionStatus defines the observed state of AlertReaction
 type AlertReactionStatus struct {
+	// ObservedGeneration is the generation of the spec that the conditions were computed from
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// LastTriggered indicates when this AlertReaction was last triggered
 	LastTriggered *metav1.Time `json:"lastTriggered,omitempty"`
 
@@ -290,6 +417,72 @@ type AlertReactionStatus struct {
 
 	// Conditions represent the latest available observations of the AlertReaction's state
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// LastAlert is the data of the last firing alert that triggered this AlertReaction.
+	// Manual triggers without labels run the actions with it.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	LastAlert *runtime.RawExtension `json:"lastAlert,omitempty"`
+
+	// LastManualTrigger records the last manual run requested with the karo.io/trigger annotation
+	LastManualTrigger *ManualTrigger `json:"lastManualTrigger,omitempty"`
+
+	// Query reports the last evaluation of the query trigger
+	Query *QueryStatus `json:"query,omitempty"`
+}
+
+// QueryStatus reports the evaluation of a query trigger
+type QueryStatus struct {
+	// LastEvaluationTime is when the expression was last evaluated
+	LastEvaluationTime *metav1.Time `json:"lastEvaluationTime,omitempty"`
+
+	// ActiveSeries is the number of series returned by the last successful evaluation
+	ActiveSeries int32 `json:"activeSeries"`
+
+	// LastError is the error of the last evaluation, if it failed
+	LastError string `json:"lastError,omitempty"`
+
+	// Expr is the expression the firing series were returned by
+	Expr string `json:"expr,omitempty"`
+
+	// Series are the series that are firing, so they do not fire again after an operator restart
+	Series []QuerySeries `json:"series,omitempty"`
+}
+
+// QuerySeries is a firing series of a query trigger
+type QuerySeries struct {
+	// Fingerprint identifies the series
+	Fingerprint string `json:"fingerprint"`
+
+	// Labels of the series, including the alertname of its alert
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Value of the series when it started firing
+	Value string `json:"value,omitempty"`
+
+	// StartsAt is when the series started firing
+	StartsAt metav1.Time `json:"startsAt"`
+}
+
+// ManualTrigger records a manual run of the actions of an AlertReaction
+type ManualTrigger struct {
+	// Nonce is the value of the karo.io/trigger annotation that requested the run
+	Nonce string `json:"nonce"`
+
+	// TriggeredBy is the user who requested the run, from the karo.io/triggered-by annotation set by
+	// the admission webhook. Without the admission webhook, it is the field manager that set karo.io/trigger.
+	TriggeredBy string `json:"triggeredBy,omitempty"`
+
+	// TriggeredAt is when the run was handled
+	TriggeredAt metav1.Time `json:"triggeredAt"`
+
+	// Source of the alert the actions ran with: "labels" for a synthetic alert built from the
+	// karo.io/trigger-labels annotation, or "lastAlert" for the last alert that triggered the AlertReaction
+	Source string `json:"source"`
+
+	// Errors that occurred while creating the jobs
+	Errors []string `json:"errors,omitempty"`
 }
 
 // JobReference contains a reference to a created job
@@ -311,6 +504,8 @@ type JobReference struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:storageversion
 // +kubebuilder:printcolumn:name="Alert Name",type=string,JSONPath=`.spec.alertName`
+// +kubebuilder:printcolumn:name="Alert Names",type=string,JSONPath=`.spec.alertNames`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 // +kubebuilder:printcolumn:name="Actions",type=integer,JSONPath=`.spec.actions[*].name | length`
 // +kubebuilder:printcolumn:name="Last Triggered",type=date,JSONPath=`.status.lastTriggered`
 // +kubebuilder:printcolumn:name="Trigger Count",type=integer,JSONPath=`.status.triggerCount`
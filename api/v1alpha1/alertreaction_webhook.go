@@ -0,0 +1,306 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// DefaultQueryInterval is the interval set on query triggers without an interval
+const DefaultQueryInterval = "1m"
+
+// SetupWebhookWithManager registers the defaulting and validating admission webhooks for AlertReactions
+func (r *AlertReaction) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(&alertReactionDefaulter{}).
+		WithValidator(&alertReactionValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-karo-io-v1alpha1-alertreaction,mutating=true,failurePolicy=fail,sideEffects=None,groups=karo.io,resources=alertreactions,verbs=create;update,versions=v1alpha1,name=malertreaction.karo.io,admissionReviewVersions=v1
+
+// alertReactionDefaulter sets the defaults of AlertReactions on admission
+// +kubebuilder:object:generate=false
+type alertReactionDefaulter struct{}
+
+// Default implements admission.CustomDefaulter
+func (d *alertReactionDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	alertReaction, ok := obj.(*AlertReaction)
+	if !ok {
+		return fmt.Errorf("expected an AlertReaction but got %T", obj)
+	}
+	alertReaction.Default()
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	var oldAlertReaction *AlertReaction
+	if len(req.OldObject.Raw) > 0 {
+		oldAlertReaction = &AlertReaction{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldAlertReaction); err != nil {
+			return fmt.Errorf("failed to decode the previous AlertReaction: %w", err)
+		}
+	}
+	alertReaction.setTriggeredBy(oldAlertReaction, req.UserInfo.Username)
+	return nil
+}
+
+// setTriggeredBy records the user who changed the trigger annotation in the triggered-by annotation.
+// Clients cannot set the triggered-by annotation themselves: unless the trigger annotation changed,
+// its previous value is kept.
+func (r *AlertReaction) setTriggeredBy(old *AlertReaction, username string) {
+	var oldTrigger, oldTriggeredBy string
+	if old != nil {
+		oldTrigger = old.Annotations[TriggerAnnotation]
+		oldTriggeredBy = old.Annotations[TriggeredByAnnotation]
+	}
+
+	triggeredBy := oldTriggeredBy
+	if trigger := r.Annotations[TriggerAnnotation]; trigger != "" && trigger != oldTrigger {
+		triggeredBy = username
+	}
+
+	if triggeredBy == "" {
+		delete(r.Annotations, TriggeredByAnnotation)
+		return
+	}
+	if r.Annotations == nil {
+		r.Annotations = make(map[string]string)
+	}
+	r.Annotations[TriggeredByAnnotation] = triggeredBy
+}
+
+// +kubebuilder:webhook:path=/validate-karo-io-v1alpha1-alertreaction,mutating=false,failurePolicy=fail,sideEffects=None,groups=karo.io,resources=alertreactions,verbs=create;update,versions=v1alpha1,name=valertreaction.karo.io,admissionReviewVersions=v1
+
+// alertReactionValidator rejects invalid AlertReactions on admission
+// +kubebuilder:object:generate=false
+type alertReactionValidator struct{}
+
+// ValidateCreate implements admission.CustomValidator
+func (v *alertReactionValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	alertReaction, ok := obj.(*AlertReaction)
+	if !ok {
+		return nil, fmt.Errorf("expected an AlertReaction but got %T", obj)
+	}
+	return nil, alertReaction.Validate()
+}
+
+// ValidateUpdate implements admission.CustomValidator
+func (v *alertReactionValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	alertReaction, ok := newObj.(*AlertReaction)
+	if !ok {
+		return nil, fmt.Errorf("expected an AlertReaction but got %T", newObj)
+	}
+	return nil, alertReaction.Validate()
+}
+
+// ValidateDelete implements admission.CustomValidator
+func (v *alertReactionValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// Default sets the defaults of unset fields
+func (r *AlertReaction) Default() {
+	if r.Spec.Mode == "" {
+		r.Spec.Mode = ReactionModeAlert
+	}
+	if r.Spec.Query != nil && r.Spec.Query.Interval == "" {
+		r.Spec.Query.Interval = DefaultQueryInterval
+	}
+}
+
+// Validate checks the AlertReaction for errors the API server schema cannot detect, which would
+// otherwise only surface when alerts are processed. It returns an Invalid error listing every
+// invalid field.
+func (r *AlertReaction) Validate() error {
+	allErrs := r.Spec.Validate(field.NewPath("spec"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("AlertReaction").GroupKind(), r.Name, allErrs)
+}
+
+// Validate returns the errors in the spec that the CRD schema cannot detect, with their field paths under path
+func (s *AlertReactionSpec) Validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(s.AllAlertNames()) == 0 && len(s.Matchers) == 0 && len(s.MatcherGroups) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("alertName"), "must specify `alertName`, `alertNames`, `matchers` or `matcherGroups`"))
+	}
+
+	allErrs = append(allErrs, validateMatchers(s.Matchers, path.Child("matchers"))...)
+	for i, group := range s.MatcherGroups {
+		groupPath := path.Child("matcherGroups").Index(i)
+		if len(group) == 0 {
+			allErrs = append(allErrs, field.Required(groupPath, "must contain at least one matcher"))
+		}
+		allErrs = append(allErrs, validateMatchers(group, groupPath)...)
+	}
+
+	if s.Query != nil && s.Query.Interval != "" {
+		if interval, err := time.ParseDuration(s.Query.Interval); err != nil || interval <= 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("query", "interval"), s.Query.Interval, "must be a positive duration"))
+		}
+	}
+
+	volumeNames := make(map[string]bool, len(s.Volumes))
+	for i := range s.Volumes {
+		volumePath := path.Child("volumes").Index(i)
+		if volumeNames[s.Volumes[i].Name] {
+			allErrs = append(allErrs, field.Duplicate(volumePath.Child("name"), s.Volumes[i].Name))
+		}
+		volumeNames[s.Volumes[i].Name] = true
+		allErrs = append(allErrs, s.Volumes[i].VolumeSource.validate(volumePath)...)
+	}
+
+	allErrs = append(allErrs, validateActions(s.Actions, volumeNames, path.Child("actions"))...)
+	allErrs = append(allErrs, validateActions(s.OnResolved, volumeNames, path.Child("onResolved"))...)
+
+	// Group jobs belong to a notification rather than a single alert, so they cannot be cancelled
+	// when one resolves
+	if s.Mode == ReactionModeGroup {
+		for i, action := range s.Actions {
+			if action.CancelOnResolve != "" {
+				allErrs = append(allErrs, field.Forbidden(path.Child("actions").Index(i).Child("cancelOnResolve"), "is not supported in group mode"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func validateMatchers(matchers []AlertMatcher, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, matcher := range matchers {
+		if matcher.Operator != MatchOperatorRegexMatch && matcher.Operator != MatchOperatorRegexNotMatch {
+			continue
+		}
+		if _, err := regexp.Compile(matcher.Value); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("value"), matcher.Value,
+				fmt.Sprintf("invalid regular expression: %v", err)))
+		}
+	}
+	return allErrs
+}
+
+func validateActions(actions []Action, volumeNames map[string]bool, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, action := range actions {
+		actionPath := path.Index(i)
+
+		for j, envVar := range action.Env {
+			allErrs = append(allErrs, envVar.validate(actionPath.Child("env").Index(j))...)
+		}
+
+		if action.Resources != nil {
+			resourcesPath := actionPath.Child("resources")
+			allErrs = append(allErrs, validateResourceList(action.Resources.Limits, resourcesPath.Child("limits"))...)
+			allErrs = append(allErrs, validateResourceList(action.Resources.Requests, resourcesPath.Child("requests"))...)
+		}
+
+		for j, volumeMount := range action.VolumeMounts {
+			if !volumeNames[volumeMount.Name] {
+				allErrs = append(allErrs, field.NotFound(actionPath.Child("volumeMounts").Index(j).Child("name"), volumeMount.Name))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func validateResourceList(resources map[string]string, path *field.Path) field.ErrorList {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var allErrs field.ErrorList
+	for _, name := range names {
+		value := resources[name]
+		if _, err := resource.ParseQuantity(value); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Key(name), value, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+func (e *EnvVar) validate(path *field.Path) field.ErrorList {
+	if e.ValueFrom == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	if e.Value != "" {
+		allErrs = append(allErrs, field.Invalid(path.Child("valueFrom"), "", "may not be specified when `value` is not empty"))
+	}
+
+	var sources []string
+	if e.ValueFrom.AlertRef != nil {
+		sources = append(sources, "alertRef")
+	}
+	if e.ValueFrom.ConfigMapKeyRef != nil {
+		sources = append(sources, "configMapKeyRef")
+	}
+	if e.ValueFrom.SecretKeyRef != nil {
+		sources = append(sources, "secretKeyRef")
+	}
+	switch {
+	case len(sources) == 0:
+		allErrs = append(allErrs, field.Required(path.Child("valueFrom"), "must specify one of: `alertRef`, `configMapKeyRef` or `secretKeyRef`"))
+	case len(sources) > 1:
+		for _, source := range sources[1:] {
+			allErrs = append(allErrs, field.Forbidden(path.Child("valueFrom", source), "may not specify more than one value source"))
+		}
+	}
+	return allErrs
+}
+
+func (v *VolumeSource) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	var sources []string
+	if v.ConfigMap != nil {
+		sources = append(sources, "configMap")
+	}
+	if v.Secret != nil {
+		sources = append(sources, "secret")
+	}
+	if v.EmptyDir != nil {
+		sources = append(sources, "emptyDir")
+		if v.EmptyDir.SizeLimit != "" {
+			if _, err := resource.ParseQuantity(v.EmptyDir.SizeLimit); err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Child("emptyDir", "sizeLimit"), v.EmptyDir.SizeLimit, err.Error()))
+			}
+		}
+	}
+	if v.PersistentVolumeClaim != nil {
+		sources = append(sources, "persistentVolumeClaim")
+	}
+	if v.HostPath != nil {
+		sources = append(sources, "hostPath")
+	}
+
+	switch {
+	case len(sources) == 0:
+		allErrs = append(allErrs, field.Required(path, "must specify one of: `configMap`, `secret`, `emptyDir`, `persistentVolumeClaim` or `hostPath`"))
+	case len(sources) > 1:
+		for _, source := range sources[1:] {
+			allErrs = append(allErrs, field.Forbidden(path.Child(source), "may not specify more than one volume source"))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,278 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func validAlertReaction() *AlertReaction {
+	return &AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-reaction", Namespace: "default"},
+		Spec: AlertReactionSpec{
+			AlertName: "HighCPUUsage",
+			Matchers: []AlertMatcher{
+				{Name: "instance", Operator: MatchOperatorRegexMatch, Value: "^web-.*"},
+			},
+			Volumes: []Volume{
+				{Name: "config", VolumeSource: VolumeSource{ConfigMap: &ConfigMapVolumeSource{Name: "config"}}},
+			},
+			Actions: []Action{
+				{
+					Name:  "scale-up",
+					Image: "bitnami/kubectl:latest",
+					Env: []EnvVar{
+						{Name: "INSTANCE", ValueFrom: &EnvVarSource{AlertRef: &AlertFieldSelector{FieldPath: "labels.instance"}}},
+					},
+					Resources: &ResourceRequirements{
+						Limits: map[string]string{"cpu": "500m", "memory": "256Mi"},
+					},
+					VolumeMounts: []VolumeMount{{Name: "config", MountPath: "/config"}},
+				},
+			},
+		},
+	}
+}
+
+func TestAlertReaction_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*AlertReaction)
+		fields []string
+	}{
+		{
+			name:   "valid",
+			modify: func(*AlertReaction) {},
+		},
+		{
+			name: "matchers without alert names",
+			modify: func(r *AlertReaction) {
+				r.Spec.AlertName = ""
+			},
+		},
+		{
+			name: "neither alert names nor matchers",
+			modify: func(r *AlertReaction) {
+				r.Spec.AlertName = ""
+				r.Spec.Matchers = nil
+			},
+			fields: []string{"spec.alertName"},
+		},
+		{
+			name: "invalid regular expression",
+			modify: func(r *AlertReaction) {
+				r.Spec.Matchers[0].Value = "[web"
+			},
+			fields: []string{"spec.matchers[0].value"},
+		},
+		{
+			name: "matcher groups without alert names",
+			modify: func(r *AlertReaction) {
+				r.Spec.AlertName = ""
+				r.Spec.Matchers = nil
+				r.Spec.MatcherGroups = [][]AlertMatcher{{{Name: "severity", Operator: MatchOperatorEqual, Value: "critical"}}}
+			},
+		},
+		{
+			name: "empty matcher group and invalid regular expression in a group",
+			modify: func(r *AlertReaction) {
+				r.Spec.MatcherGroups = [][]AlertMatcher{
+					{},
+					{{Name: "team", Operator: MatchOperatorRegexNotMatch, Value: "(sre"}},
+				}
+			},
+			fields: []string{"spec.matcherGroups[0]", "spec.matcherGroups[1][0].value"},
+		},
+		{
+			name: "invalid resource quantity",
+			modify: func(r *AlertReaction) {
+				r.Spec.Actions[0].Resources.Limits["memory"] = "lots"
+			},
+			fields: []string{"spec.actions[0].resources.limits[memory]"},
+		},
+		{
+			name: "volume mount of an undefined volume",
+			modify: func(r *AlertReaction) {
+				r.Spec.OnResolved = []Action{
+					{Name: "notify", Image: "busybox", VolumeMounts: []VolumeMount{{Name: "missing", MountPath: "/data"}}},
+				}
+			},
+			fields: []string{"spec.onResolved[0].volumeMounts[0].name"},
+		},
+		{
+			name: "env var with several sources",
+			modify: func(r *AlertReaction) {
+				r.Spec.Actions[0].Env[0].ValueFrom.SecretKeyRef = &SecretKeySelector{Name: "secret", Key: "key"}
+			},
+			fields: []string{"spec.actions[0].env[0].valueFrom.secretKeyRef"},
+		},
+		{
+			name: "env var without a source",
+			modify: func(r *AlertReaction) {
+				r.Spec.Actions[0].Env[0].ValueFrom = &EnvVarSource{}
+			},
+			fields: []string{"spec.actions[0].env[0].valueFrom"},
+		},
+		{
+			name: "volume with several sources",
+			modify: func(r *AlertReaction) {
+				r.Spec.Volumes[0].EmptyDir = &EmptyDirVolumeSource{}
+			},
+			fields: []string{"spec.volumes[0].emptyDir"},
+		},
+		{
+			name: "volume without a source",
+			modify: func(r *AlertReaction) {
+				r.Spec.Volumes = append(r.Spec.Volumes, Volume{Name: "data"})
+			},
+			fields: []string{"spec.volumes[1]"},
+		},
+		{
+			name: "duplicate volume and invalid size limit",
+			modify: func(r *AlertReaction) {
+				r.Spec.Volumes = append(r.Spec.Volumes, Volume{
+					Name:         "config",
+					VolumeSource: VolumeSource{EmptyDir: &EmptyDirVolumeSource{SizeLimit: "big"}},
+				})
+			},
+			fields: []string{"spec.volumes[1].name", "spec.volumes[1].emptyDir.sizeLimit"},
+		},
+		{
+			name: "cancelOnResolve in group mode",
+			modify: func(r *AlertReaction) {
+				r.Spec.Mode = ReactionModeGroup
+				r.Spec.Actions[0].CancelOnResolve = CancelPolicyDelete
+			},
+			fields: []string{"spec.actions[0].cancelOnResolve"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alertReaction := validAlertReaction()
+			tt.modify(alertReaction)
+
+			_, err := (&alertReactionValidator{}).ValidateCreate(context.TODO(), alertReaction)
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			if !apierrors.IsInvalid(err) {
+				t.Fatalf("Expected an Invalid error, got %v", err)
+			}
+			causes := err.(*apierrors.StatusError).ErrStatus.Details.Causes
+			if len(causes) != len(tt.fields) {
+				t.Fatalf("Expected %d causes, got %v", len(tt.fields), causes)
+			}
+			for i, field := range tt.fields {
+				if causes[i].Field != field {
+					t.Errorf("Expected cause %d on %s, got %s", i, field, causes[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestAlertReactionSpec_AlertNames(t *testing.T) {
+	spec := AlertReactionSpec{AlertName: "HighCPUUsage", AlertNames: []string{"HighMemoryUsage", "HighCPUUsage"}}
+	if names := spec.AllAlertNames(); len(names) != 2 || names[0] != "HighCPUUsage" || names[1] != "HighMemoryUsage" {
+		t.Errorf("Expected the alert names without duplicates, got %v", names)
+	}
+	if !spec.MatchesAlertName("HighMemoryUsage") || spec.MatchesAlertName("DiskFull") {
+		t.Error("Expected only the listed alert names to match")
+	}
+
+	// Without alert names, alerts with any name match
+	if spec := (AlertReactionSpec{}); len(spec.AllAlertNames()) != 0 || !spec.MatchesAlertName("DiskFull") {
+		t.Error("Expected alerts with any name to match")
+	}
+}
+
+func TestAlertReaction_ValidateUpdate(t *testing.T) {
+	oldAlertReaction := validAlertReaction()
+	newAlertReaction := validAlertReaction()
+	newAlertReaction.Spec.Matchers[0].Value = "(web"
+
+	_, err := (&alertReactionValidator{}).ValidateUpdate(context.TODO(), oldAlertReaction, newAlertReaction)
+	if err == nil || !strings.Contains(err.Error(), "spec.matchers[0].value") {
+		t.Errorf("Expected the update to be rejected, got %v", err)
+	}
+}
+
+func TestAlertReaction_Default(t *testing.T) {
+	alertReaction := validAlertReaction()
+	alertReaction.Spec.Query = &QueryTrigger{Expr: "up == 0"}
+
+	ctx := admission.NewContextWithRequest(context.TODO(), admission.Request{})
+	if err := (&alertReactionDefaulter{}).Default(ctx, alertReaction); err != nil {
+		t.Fatalf("Default failed: %v", err)
+	}
+	if alertReaction.Spec.Mode != ReactionModeAlert {
+		t.Errorf("Expected mode %q, got %q", ReactionModeAlert, alertReaction.Spec.Mode)
+	}
+	if alertReaction.Spec.Query.Interval != DefaultQueryInterval {
+		t.Errorf("Expected query interval %q, got %q", DefaultQueryInterval, alertReaction.Spec.Query.Interval)
+	}
+
+	// Set fields are kept
+	alertReaction.Spec.Mode = ReactionModeGroup
+	alertReaction.Spec.Query.Interval = "5m"
+	alertReaction.Default()
+	if alertReaction.Spec.Mode != ReactionModeGroup || alertReaction.Spec.Query.Interval != "5m" {
+		t.Errorf("Expected set fields to be kept, got %+v", alertReaction.Spec)
+	}
+}
+
+func TestAlertReaction_DefaultTriggeredBy(t *testing.T) {
+	defaulter := &alertReactionDefaulter{}
+	admit := func(oldAlertReaction, alertReaction *AlertReaction, username string) {
+		t.Helper()
+		req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UserInfo: authenticationv1.UserInfo{Username: username},
+		}}
+		if oldAlertReaction != nil {
+			raw, err := json.Marshal(oldAlertReaction)
+			if err != nil {
+				t.Fatalf("Failed to encode AlertReaction: %v", err)
+			}
+			req.OldObject.Raw = raw
+		}
+		if err := defaulter.Default(admission.NewContextWithRequest(context.TODO(), req), alertReaction); err != nil {
+			t.Fatalf("Default failed: %v", err)
+		}
+	}
+
+	// Clients cannot claim to be someone else
+	created := validAlertReaction()
+	created.Annotations = map[string]string{TriggeredByAnnotation: "admin"}
+	admit(nil, created, "jane")
+	if _, ok := created.Annotations[TriggeredByAnnotation]; ok {
+		t.Errorf("Expected the triggered-by annotation of the client to be removed, got %v", created.Annotations)
+	}
+
+	// Changing the trigger records the authenticated user
+	triggered := created.DeepCopy()
+	triggered.Annotations = map[string]string{TriggerAnnotation: "1", TriggeredByAnnotation: "admin"}
+	admit(created, triggered, "jane")
+	if got := triggered.Annotations[TriggeredByAnnotation]; got != "jane" {
+		t.Errorf("Expected the requester to be jane, got %q", got)
+	}
+
+	// Other updates keep the recorded user
+	updated := triggered.DeepCopy()
+	updated.Annotations = map[string]string{TriggerAnnotation: "1", TriggeredByAnnotation: "admin"}
+	admit(triggered, updated, "bob")
+	if got := updated.Annotations[TriggeredByAnnotation]; got != "jane" {
+		t.Errorf("Expected the requester to stay jane, got %q", got)
+	}
+}
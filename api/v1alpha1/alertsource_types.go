@@ -0,0 +1,68 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AlertSourceSpec defines how payloads of a generic JSON webhook are turned into alerts
+type AlertSourceSpec struct {
+	// Path is the ingestion path of this source below /sources/<namespace>/
+	// For example, "ci/builds" in namespace "default" accepts payloads POSTed to /sources/default/ci/builds
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9][A-Za-z0-9._/-]*$`
+	Path string `json:"path"`
+
+	// AlertsPath is a JSONPath expression selecting the alerts in the payload (e.g., "{.events[*]}")
+	// If not specified, the whole payload is treated as a single alert
+	AlertsPath string `json:"alertsPath,omitempty"`
+
+	// Mapping defines how the fields of each alert are extracted
+	// +kubebuilder:validation:Required
+	Mapping AlertSourceMapping `json:"mapping"`
+}
+
+// AlertSourceMapping maps alert fields to JSONPath expressions evaluated against a single alert
+type AlertSourceMapping struct {
+	// AlertName is the JSONPath expression of the alert name (e.g., "{.check.name}")
+	// Alerts without an alert name are skipped
+	// +kubebuilder:validation:Required
+	AlertName string `json:"alertName"`
+
+	// Status is the JSONPath expression of the alert status
+	// If not specified, all alerts are treated as firing
+	Status string `json:"status,omitempty"`
+
+	// ResolvedValues lists the status values that mark an alert as resolved
+	// If not specified, only "resolved" does
+	ResolvedValues []string `json:"resolvedValues,omitempty"`
+
+	// Fingerprint is the JSONPath expression of a value uniquely identifying the alert
+	// If not specified, the fingerprint is derived from the alert name and labels
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// Labels maps label names to JSONPath expressions
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations maps annotation names to JSONPath expressions
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Path",type=string,JSONPath=`.spec.path`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// AlertSource is the Schema for the alertsources API
+type AlertSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AlertSourceSpec `json:"spec,omitempty"`
+}
+
+// AlertSourceList contains a list of AlertSource
+// +kubebuilder:object:root=true
+type AlertSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AlertSource `json:"items"`
+}
@@ -88,6 +88,20 @@ Webhook service name
 {{- printf "%s-webhook" (include "karo.fullname" .) }}
 {{- end }}
 
+{{/*
+Admission webhook service name
+*/}}
+{{- define "karo.admissionWebhookServiceName" -}}
+{{- printf "%s-admission" (include "karo.fullname" .) }}
+{{- end }}
+
+{{/*
+Admission webhook serving certificate secret name
+*/}}
+{{- define "karo.admissionWebhookSecretName" -}}
+{{- default (printf "%s-admission-tls" (include "karo.fullname" .)) .Values.operator.admissionWebhook.secretName }}
+{{- end }}
+
 {{/*
 Metrics service name
 */}}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+// AlertReconciler processes Alert resources like alerts received through the webhook
+type AlertReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Alerts processes the submitted alerts
+	Alerts *AlertReactionReconciler
+}
+
+//+kubebuilder:rbac:groups=karo.io,resources=alerts,verbs=get;list;watch
+//+kubebuilder:rbac:groups=karo.io,resources=alerts/status,verbs=get;update;patch
+
+// Reconcile processes each generation of an Alert once with the AlertReactions in its namespace,
+// and records the outcome in its status
+func (r *AlertReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var alert alertreactionv1alpha1.Alert
+	if err := r.Get(ctx, req.NamespacedName, &alert); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if alert.Status.ObservedGeneration == alert.Generation {
+		return ctrl.Result{}, nil
+	}
+
+	status := alertreactionv1alpha1.AlertStatus{ObservedGeneration: alert.Generation}
+
+	alertName := alert.Spec.Labels["alertname"]
+	if alertName == "" {
+		status.Errors = []string{"labels must contain alertname"}
+	} else {
+		logger.Info("Processing Alert", "alertName", alertName, "status", alert.Spec.Status)
+
+		// Only AlertReactions in the namespace of the Alert react to it, so permission to create
+		// Alerts in a namespace does not allow running the jobs of other namespaces
+		result, err := r.Alerts.ProcessNamespacedAlertWithResult(ctx, alert.Namespace, alertName, resourceToAlert(&alert))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		status.MatchedReactions = result.MatchedReactions
+		status.Jobs = result.Jobs
+		status.Errors = result.Errors
+	}
+
+	now := metav1.NewTime(time.Now())
+	status.ProcessedAt = &now
+
+	// Patch without optimistic locking, so changes made while processing do not cause the
+	// same generation to be processed twice
+	patch := client.MergeFrom(alert.DeepCopy())
+	alert.Status = status
+	if err := r.Status().Patch(ctx, &alert, patch); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// resourceToAlert converts an Alert resource into alert data, as the webhook server does for AlertManager alerts.
+// The UID of the Alert is its fingerprint, so resolving it cancels the jobs of the firing alert.
+func resourceToAlert(alert *alertreactionv1alpha1.Alert) map[string]interface{} {
+	status := alert.Spec.Status
+	if status == "" {
+		status = AlertStatusFiring
+	}
+	startsAt := alert.CreationTimestamp.Time
+	if alert.Spec.StartsAt != nil {
+		startsAt = alert.Spec.StartsAt.Time
+	}
+
+	alertData := map[string]interface{}{
+		"status":      status,
+		"startsAt":    startsAt.UTC().Format(time.RFC3339),
+		"fingerprint": string(alert.UID),
+		"alert":       alert.Namespace + "/" + alert.Name,
+	}
+	setAlertMetadata(alertData, alert.Spec.Labels, alert.Spec.Annotations)
+
+	return alertData
+}
+
+// setAlertMetadata adds labels and annotations to alert data, both as maps and as
+// "labels.<name>" and "annotations.<name>" for direct access
+func setAlertMetadata(alertData map[string]interface{}, labels, annotations map[string]string) {
+	alertLabels := make(map[string]interface{})
+	for k, v := range labels {
+		alertLabels[k] = v
+		alertData["labels."+k] = v
+	}
+	alertData["labels"] = alertLabels
+
+	alertAnnotations := make(map[string]interface{})
+	for k, v := range annotations {
+		alertAnnotations[k] = v
+		alertData["annotations."+k] = v
+	}
+	alertData["annotations"] = alertAnnotations
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *AlertReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&alertreactionv1alpha1.Alert{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
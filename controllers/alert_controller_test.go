@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+func TestAlertReconciler_Reconcile(t *testing.T) {
+	alertReconciler, _ := setupTestEmpty()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(alertReconciler.Scheme).
+		WithStatusSubresource(&alertreactionv1alpha1.AlertReaction{}, &alertreactionv1alpha1.Alert{}).
+		WithIndex(&alertreactionv1alpha1.AlertReaction{}, AlertNameField, AlertNameIndexer).
+		Build()
+	alertReconciler.Client = fakeClient
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "deploy-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "DeploymentFailed",
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "rollback", Image: "busybox:latest"},
+			},
+			OnResolved: []alertreactionv1alpha1.Action{
+				{Name: "notify", Image: "busybox:latest"},
+			},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	// AlertReactions in other namespaces do not react to the Alert
+	otherAlertReaction := alertReaction.DeepCopy()
+	otherAlertReaction.Namespace = "other"
+	otherAlertReaction.ResourceVersion = ""
+	if err := fakeClient.Create(context.TODO(), otherAlertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	alert := &alertreactionv1alpha1.Alert{
+		ObjectMeta: metav1.ObjectMeta{Name: "deploy-api", Namespace: "default", Generation: 1},
+		Spec: alertreactionv1alpha1.AlertSpec{
+			Labels: map[string]string{"alertname": "DeploymentFailed", "deployment": "api"},
+			Status: AlertStatusFiring,
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alert); err != nil {
+		t.Fatalf("Failed to create Alert: %v", err)
+	}
+
+	reconciler := &AlertReconciler{Client: fakeClient, Scheme: alertReconciler.Scheme, Alerts: alertReconciler}
+	key := types.NamespacedName{Name: "deploy-api", Namespace: "default"}
+	reconcile := func() *alertreactionv1alpha1.Alert {
+		t.Helper()
+		if _, err := reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("Reconcile failed: %v", err)
+		}
+		var updated alertreactionv1alpha1.Alert
+		if err := fakeClient.Get(context.TODO(), key, &updated); err != nil {
+			t.Fatalf("Failed to get Alert: %v", err)
+		}
+		return &updated
+	}
+
+	updated := reconcile()
+	if updated.Status.ObservedGeneration != updated.Generation {
+		t.Errorf("Expected observed generation %d, got %d", updated.Generation, updated.Status.ObservedGeneration)
+	}
+	if len(updated.Status.MatchedReactions) != 1 || updated.Status.MatchedReactions[0] != "default/deploy-reaction" {
+		t.Errorf("Expected the matched reaction in the status, got %v", updated.Status.MatchedReactions)
+	}
+	if len(updated.Status.Jobs) != 1 {
+		t.Errorf("Expected 1 job in the status, got %v", updated.Status.Jobs)
+	}
+	var otherJobs batchv1.JobList
+	if err := fakeClient.List(context.TODO(), &otherJobs, client.InNamespace("other")); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(otherJobs.Items) != 0 {
+		t.Errorf("Expected no jobs in other namespaces, got %d", len(otherJobs.Items))
+	}
+
+	// The same generation is processed only once
+	reconcile()
+	if triggerCount := getTriggerCount(t, fakeClient); triggerCount != 1 {
+		t.Errorf("Expected 1 trigger after reconciling twice, got %d", triggerCount)
+	}
+
+	// Resolving the Alert runs the onResolved actions
+	updated.Spec.Status = AlertStatusResolved
+	updated.Generation++
+	if err := fakeClient.Update(context.TODO(), updated); err != nil {
+		t.Fatalf("Failed to update Alert: %v", err)
+	}
+	resolved := reconcile()
+	if len(resolved.Status.Jobs) != 1 || resolved.Status.Jobs[0] == updated.Status.Jobs[0] {
+		t.Errorf("Expected a new onResolved job in the status, got %v", resolved.Status.Jobs)
+	}
+	if triggerCount := getTriggerCount(t, fakeClient); triggerCount != 2 {
+		t.Errorf("Expected 2 triggers after resolving, got %d", triggerCount)
+	}
+}
+
+func getTriggerCount(t *testing.T, c client.Client) int64 {
+	t.Helper()
+	var alertReaction alertreactionv1alpha1.AlertReaction
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "deploy-reaction", Namespace: "default"}, &alertReaction); err != nil {
+		t.Fatalf("Failed to get AlertReaction: %v", err)
+	}
+	return alertReaction.Status.TriggerCount
+}
@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+const (
+	// groupVolumeName is the name of the volume holding the notification group of group-mode jobs
+	groupVolumeName = "karo-group"
+
+	// GroupMountPath is the directory the notification group is mounted at in group-mode jobs
+	GroupMountPath = "/etc/karo/group"
+
+	// GroupFileName is the name of the JSON file containing the notification group
+	GroupFileName = "group.json"
+
+	// GroupFileEnvVar is the environment variable holding the path of the notification group file
+	GroupFileEnvVar = "KARO_GROUP_FILE"
+)
+
+// AlertGroup is a notification group of alerts delivered in a single webhook payload
+type AlertGroup struct {
+	GroupKey          string            `json:"groupKey"`
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+
+	// Alerts contains the data of each alert in the group, as passed to ProcessAlert
+	Alerts []map[string]interface{} `json:"alerts"`
+}
+
+// ProcessGroup creates jobs for all matching group-mode AlertReactions for the given notification group.
+// Each reaction runs its actions once for the firing alerts it matches and its onResolved actions once
+// for the resolved alerts it matches.
+func (r *AlertReactionReconciler) ProcessGroup(ctx context.Context, group *AlertGroup) error {
+	logger := log.FromContext(ctx)
+
+	alertReactions, err := r.listGroupAlertReactions(ctx, group)
+	if err != nil {
+		return err
+	}
+
+	now := metav1.NewTime(time.Now())
+
+	for i := range alertReactions {
+		alertReaction := &alertReactions[i]
+
+		jobRefs := r.runGroup(ctx, alertReaction, group, now)
+		if len(jobRefs) == 0 {
+			continue
+		}
+
+		alertReaction.Status.LastTriggered = &now
+		alertReaction.Status.TriggerCount++
+		alertReaction.Status.LastJobsCreated = jobRefs
+
+		if err := r.Status().Update(ctx, alertReaction); err != nil {
+			logger.Error(err, "failed to update AlertReaction status", "alertReaction", alertReaction.Name)
+			// Continue processing other AlertReactions even if one fails
+			continue
+		}
+	}
+
+	return nil
+}
+
+// listGroupAlertReactions lists the group-mode AlertReactions for the alert names in a group.
+// AlertReactions for several of the names, or for any name, are listed for each name but returned once.
+func (r *AlertReactionReconciler) listGroupAlertReactions(ctx context.Context, group *AlertGroup) ([]alertreactionv1alpha1.AlertReaction, error) {
+	var alertReactions []alertreactionv1alpha1.AlertReaction
+	listed := make(map[string]bool)
+	found := make(map[types.NamespacedName]bool)
+	for _, alertData := range group.Alerts {
+		alertName := groupAlertName(alertData)
+		if listed[alertName] {
+			continue
+		}
+		listed[alertName] = true
+
+		items, err := r.listAlertReactions(ctx, alertName)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			key := types.NamespacedName{Namespace: item.Namespace, Name: item.Name}
+			if item.Spec.Mode != alertreactionv1alpha1.ReactionModeGroup || found[key] {
+				continue
+			}
+			found[key] = true
+			alertReactions = append(alertReactions, item)
+		}
+	}
+	return alertReactions, nil
+}
+
+// runGroup runs the actions of a group-mode AlertReaction for the firing alerts of a group it matches
+// and its onResolved actions for the resolved alerts it matches
+func (r *AlertReactionReconciler) runGroup(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction, group *AlertGroup, now metav1.Time) []alertreactionv1alpha1.JobReference {
+	logger := log.FromContext(ctx)
+
+	var jobRefs []alertreactionv1alpha1.JobReference
+	for _, status := range []string{AlertStatusFiring, AlertStatusResolved} {
+		actions := alertReaction.Spec.Actions
+		if status == AlertStatusResolved {
+			actions = alertReaction.Spec.OnResolved
+		}
+		if len(actions) == 0 {
+			continue
+		}
+
+		alerts := r.matchingGroupAlerts(alertReaction, group, status)
+		if len(alerts) == 0 {
+			continue
+		}
+
+		if status == AlertStatusFiring {
+			if err := recordLastAlert(alertReaction, alerts[len(alerts)-1]); err != nil {
+				logger.Error(err, "failed to record last alert", "alertReaction", alertReaction.Name)
+			}
+		}
+
+		logger.Info("Processing notification group", "alertReaction", alertReaction.Name, "groupKey", group.GroupKey, "status", status, "alerts", len(alerts))
+
+		groupData := group.jobData(status, alerts)
+		for _, action := range actions {
+			job, err := r.createGroupJob(ctx, alertReaction, action, groupData)
+			if err != nil {
+				logger.Error(err, "failed to create job for action", "actionName", action.Name, "alertReaction", alertReaction.Name)
+				continue
+			}
+
+			logger.Info("Created job for notification group", "jobName", job.Name, "actionName", action.Name, "alertReaction", alertReaction.Name)
+
+			jobRefs = append(jobRefs, alertreactionv1alpha1.JobReference{
+				Name:       job.Name,
+				Namespace:  job.Namespace,
+				ActionName: action.Name,
+				CreatedAt:  now,
+			})
+		}
+	}
+
+	return jobRefs
+}
+
+// matchingGroupAlerts returns the alerts of a group with the given status that match an AlertReaction
+func (r *AlertReactionReconciler) matchingGroupAlerts(alertReaction *alertreactionv1alpha1.AlertReaction, group *AlertGroup, status string) []map[string]interface{} {
+	var alerts []map[string]interface{}
+	for _, alertData := range group.Alerts {
+		if alertStatus(alertData) != status {
+			continue
+		}
+		if r.alertMatches(alertReaction, groupAlertName(alertData), alertData) {
+			alerts = append(alerts, alertData)
+		}
+	}
+	return alerts
+}
+
+// createGroupJob creates a job for an action together with a ConfigMap holding the notification group.
+// The ConfigMap is owned by the job, so it is cleaned up with it.
+func (r *AlertReactionReconciler) createGroupJob(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction, action alertreactionv1alpha1.Action, groupData map[string]interface{}) (*batchv1.Job, error) {
+	content, err := json.MarshalIndent(groupData, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode notification group: %w", err)
+	}
+
+	job, err := r.buildGroupJob(ctx, alertReaction, action, groupData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create job %s: %w", job.Name, err)
+	}
+
+	// The pod waits for the ConfigMap volume, so it can be created once the job UID is known
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name,
+			Namespace: job.Namespace,
+			Labels:    job.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: batchv1.SchemeGroupVersion.String(),
+					Kind:       "Job",
+					Name:       job.Name,
+					UID:        job.UID,
+				},
+			},
+		},
+		Data: map[string]string{GroupFileName: string(content)},
+	}
+	if err := r.Create(ctx, configMap); err != nil {
+		if deleteErr := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); deleteErr != nil {
+			log.FromContext(ctx).Error(deleteErr, "failed to delete job without notification group", "jobName", job.Name)
+		}
+		return nil, fmt.Errorf("failed to create notification group ConfigMap %s: %w", configMap.Name, err)
+	}
+
+	return job, nil
+}
+
+// buildGroupJob builds the job for an action with the notification group ConfigMap mounted, without
+// creating anything.
+func (r *AlertReactionReconciler) buildGroupJob(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction, action alertreactionv1alpha1.Action, groupData map[string]interface{}) (*batchv1.Job, error) {
+	job, err := r.createJobFromAction(ctx, alertReaction, action, groupData)
+	if err != nil {
+		return nil, err
+	}
+
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: groupVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: job.Name},
+			},
+		},
+	})
+	container := &podSpec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      groupVolumeName,
+		MountPath: GroupMountPath,
+		ReadOnly:  true,
+	})
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  GroupFileEnvVar,
+		Value: path.Join(GroupMountPath, GroupFileName),
+	})
+
+	return job, nil
+}
+
+// NotificationData returns the notification-level fields of the group. The webhook server adds them
+// to each alert under "notification", so matchers and alertRef field paths like
+// "notification.externalURL" can use them.
+func (g *AlertGroup) NotificationData() map[string]interface{} {
+	return map[string]interface{}{
+		"groupKey":          g.GroupKey,
+		"receiver":          g.Receiver,
+		"status":            g.Status,
+		"groupLabels":       stringMapToInterface(g.GroupLabels),
+		"commonLabels":      stringMapToInterface(g.CommonLabels),
+		"commonAnnotations": stringMapToInterface(g.CommonAnnotations),
+		"externalURL":       g.ExternalURL,
+		"truncatedAlerts":   g.TruncatedAlerts,
+	}
+}
+
+// jobData returns the data provided to group-mode jobs. It is also used to resolve alertRef
+// environment variables, so fields like "commonLabels.namespace" can be referenced.
+func (g *AlertGroup) jobData(status string, alerts []map[string]interface{}) map[string]interface{} {
+	alertList := make([]interface{}, len(alerts))
+	for i, alert := range alerts {
+		alertList[i] = alert
+	}
+
+	data := g.NotificationData()
+	data["status"] = status
+	data["alerts"] = alertList
+	return data
+}
+
+func groupAlertName(alertData map[string]interface{}) string {
+	if labels, ok := alertData["labels"].(map[string]interface{}); ok {
+		if alertName, ok := labels["alertname"].(string); ok {
+			return alertName
+		}
+	}
+	return ""
+}
+
+func stringMapToInterface(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
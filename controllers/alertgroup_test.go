@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+func testGroupAlert(pod, namespace string) map[string]interface{} {
+	return map[string]interface{}{
+		"status": "firing",
+		"labels": map[string]interface{}{
+			"alertname": "PodCrashLooping",
+			"pod":       pod,
+			"namespace": namespace,
+		},
+	}
+}
+
+func TestProcessGroup(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "restart-crashlooping", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "PodCrashLooping",
+			Mode:      alertreactionv1alpha1.ReactionModeGroup,
+			Matchers: []alertreactionv1alpha1.AlertMatcher{
+				{Name: "namespace", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "production"},
+			},
+			Actions: []alertreactionv1alpha1.Action{
+				{
+					Name:  "batch-restart",
+					Image: "busybox:latest",
+					Env: []alertreactionv1alpha1.EnvVar{
+						{Name: "GROUP_KEY", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+							AlertRef: &alertreactionv1alpha1.AlertFieldSelector{FieldPath: "groupKey"},
+						}},
+					},
+				},
+			},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	group := &AlertGroup{
+		GroupKey:     `{}:{alertname="PodCrashLooping"}`,
+		Receiver:     "karo",
+		Status:       "firing",
+		GroupLabels:  map[string]string{"alertname": "PodCrashLooping"},
+		CommonLabels: map[string]string{"alertname": "PodCrashLooping"},
+		Alerts: []map[string]interface{}{
+			testGroupAlert("api-1", "production"),
+			testGroupAlert("api-2", "production"),
+			testGroupAlert("api-3", "staging"),
+		},
+	}
+
+	// Per-alert processing leaves group-mode reactions alone
+	for _, alertData := range group.Alerts {
+		if err := reconciler.ProcessAlert(context.TODO(), "PodCrashLooping", alertData); err != nil {
+			t.Fatalf("ProcessAlert failed: %v", err)
+		}
+	}
+	if err := reconciler.ProcessGroup(context.TODO(), group); err != nil {
+		t.Fatalf("ProcessGroup failed: %v", err)
+	}
+
+	var jobs batchv1.JobList
+	if err := fakeClient.List(context.TODO(), &jobs, client.InNamespace("default")); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Fatalf("Expected 1 job for the group, got %d", len(jobs.Items))
+	}
+	job := jobs.Items[0]
+
+	container := job.Spec.Template.Spec.Containers[0]
+	env := make(map[string]string)
+	for _, envVar := range container.Env {
+		env[envVar.Name] = envVar.Value
+	}
+	if env[GroupFileEnvVar] != "/etc/karo/group/group.json" {
+		t.Errorf("Expected %s to point at the group file, got %q", GroupFileEnvVar, env[GroupFileEnvVar])
+	}
+	if env["GROUP_KEY"] != group.GroupKey {
+		t.Errorf("Expected GROUP_KEY to resolve from the group, got %q", env["GROUP_KEY"])
+	}
+	if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].MountPath != GroupMountPath {
+		t.Errorf("Expected the group volume to be mounted at %s, got %+v", GroupMountPath, container.VolumeMounts)
+	}
+
+	var configMap corev1.ConfigMap
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: job.Name, Namespace: "default"}, &configMap); err != nil {
+		t.Fatalf("Failed to get group ConfigMap: %v", err)
+	}
+	if len(configMap.OwnerReferences) != 1 || configMap.OwnerReferences[0].Kind != "Job" || configMap.OwnerReferences[0].Name != job.Name {
+		t.Errorf("Expected the ConfigMap to be owned by the job, got %+v", configMap.OwnerReferences)
+	}
+
+	var content struct {
+		GroupKey     string                   `json:"groupKey"`
+		CommonLabels map[string]string        `json:"commonLabels"`
+		Alerts       []map[string]interface{} `json:"alerts"`
+	}
+	if err := json.Unmarshal([]byte(configMap.Data[GroupFileName]), &content); err != nil {
+		t.Fatalf("Failed to decode group file: %v", err)
+	}
+	if content.GroupKey != group.GroupKey || content.CommonLabels["alertname"] != "PodCrashLooping" {
+		t.Errorf("Unexpected group fields: %+v", content)
+	}
+	if len(content.Alerts) != 2 {
+		t.Errorf("Expected only the 2 matching alerts in the group file, got %d", len(content.Alerts))
+	}
+
+	var updated alertreactionv1alpha1.AlertReaction
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "restart-crashlooping", Namespace: "default"}, &updated); err != nil {
+		t.Fatalf("Failed to get AlertReaction: %v", err)
+	}
+	if updated.Status.TriggerCount != 1 {
+		t.Errorf("Expected trigger count 1, got %d", updated.Status.TriggerCount)
+	}
+}
+
+func TestProcessGroup_SeveralAlertNames(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	for _, alertReaction := range []*alertreactionv1alpha1.AlertReaction{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "resource-reaction", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				AlertNames: []string{"HighCPUUsage", "HighMemoryUsage"},
+				Mode:       alertreactionv1alpha1.ReactionModeGroup,
+				Actions:    []alertreactionv1alpha1.Action{{Name: "resources", Image: "busybox:latest"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "any-reaction", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				Mode: alertreactionv1alpha1.ReactionModeGroup,
+				Matchers: []alertreactionv1alpha1.AlertMatcher{
+					{Name: "namespace", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "production"},
+				},
+				Actions: []alertreactionv1alpha1.Action{{Name: "any", Image: "busybox:latest"}},
+			},
+		},
+	} {
+		if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+			t.Fatalf("Failed to create AlertReaction: %v", err)
+		}
+	}
+
+	alert := func(alertName string) map[string]interface{} {
+		return map[string]interface{}{
+			"status": "firing",
+			"labels": map[string]interface{}{"alertname": alertName, "namespace": "production"},
+		}
+	}
+	group := &AlertGroup{
+		GroupKey:    `{}:{namespace="production"}`,
+		Status:      "firing",
+		GroupLabels: map[string]string{"namespace": "production"},
+		Alerts:      []map[string]interface{}{alert("HighCPUUsage"), alert("HighMemoryUsage")},
+	}
+	if err := reconciler.ProcessGroup(context.TODO(), group); err != nil {
+		t.Fatalf("ProcessGroup failed: %v", err)
+	}
+
+	// Each AlertReaction runs once for the group, although it is listed for both alert names
+	for _, actionName := range []string{"resources", "any"} {
+		var jobs batchv1.JobList
+		if err := fakeClient.List(context.TODO(), &jobs, client.MatchingLabels{"karo/action-name": actionName}); err != nil {
+			t.Fatalf("Failed to list jobs: %v", err)
+		}
+		if len(jobs.Items) != 1 {
+			t.Errorf("Expected 1 %s job for the group, got %d", actionName, len(jobs.Items))
+		}
+	}
+}
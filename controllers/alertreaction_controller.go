@@ -5,12 +5,16 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -22,18 +26,35 @@ import (
 	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
 )
 
+// Alert statuses reported by AlertManager
+const (
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+)
+
 // AlertReactionReconciler reconciles an AlertReaction object
 type AlertReactionReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Prometheus evaluates the query triggers of AlertReactions. Query triggers fail when it is not set.
+	Prometheus *PrometheusClient
+
+	// queries tracks the series returned by query triggers
+	queries queryTracker
+
+	// matchers caches the compiled matchers of AlertReactions
+	matchers matcherCache
 }
 
 //+kubebuilder:rbac:groups=karo.io,resources=alertreactions,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=karo.io,resources=alertreactions/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=karo.io,resources=alertreactions/finalizers,verbs=update
+//+kubebuilder:rbac:groups=karo.io,resources=alertsources,verbs=get;list;watch
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update
 //+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts;persistentvolumeclaims,verbs=get;list;watch
 
 // Reconcile handles AlertReaction resources
 func (r *AlertReactionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -42,103 +63,149 @@ func (r *AlertReactionReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	// Fetch the AlertReaction instance
 	var alertReaction alertreactionv1alpha1.AlertReaction
 	if err := r.Get(ctx, req.NamespacedName, &alertReaction); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.matchers.forget(req.NamespacedName)
+		}
 		logger.Error(err, "unable to fetch AlertReaction")
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	logger.Info("Reconciling AlertReaction", "alertName", alertReaction.Spec.AlertName)
+	logger.Info("Reconciling AlertReaction", "alertNames", alertReaction.Spec.AllAlertNames())
 
-	// Update status conditions
-	now := metav1.NewTime(time.Now())
-	condition := metav1.Condition{
-		Type:               "Ready",
-		Status:             metav1.ConditionTrue,
-		Reason:             "AlertReactionReady",
-		Message:            "AlertReaction is ready to process alerts",
-		LastTransitionTime: now,
-	}
-
-	// Update conditions if changed
-	updated := false
-	if len(alertReaction.Status.Conditions) == 0 {
-		alertReaction.Status.Conditions = []metav1.Condition{condition}
+	patch := client.MergeFrom(alertReaction.DeepCopy())
+
+	// Validate the spec and its references and report them in the status conditions
+	updated, recheckAfter := r.updateConditions(ctx, &alertReaction)
+	if ready := meta.FindStatusCondition(alertReaction.Status.Conditions, alertreactionv1alpha1.ConditionReady); updated && ready.Status != metav1.ConditionTrue {
+		logger.Info("AlertReaction is not ready", "reason", ready.Reason, "message", ready.Message)
+	}
+
+	// Run the actions if a manual trigger was requested
+	if r.handleManualTrigger(ctx, &alertReaction) {
 		updated = true
-	} else {
-		lastCondition := alertReaction.Status.Conditions[len(alertReaction.Status.Conditions)-1]
-		if lastCondition.Type != condition.Type || lastCondition.Status != condition.Status {
-			alertReaction.Status.Conditions = append(alertReaction.Status.Conditions, condition)
-			updated = true
-		}
 	}
 
+	// Query triggers are evaluated by the QueryReconciler
+
 	if updated {
-		if err := r.Status().Update(ctx, &alertReaction); err != nil {
+		// Patch without optimistic locking, so a conflict cannot run a manual or query trigger twice
+		if err := r.Status().Patch(ctx, &alertReaction, patch); err != nil {
 			logger.Error(err, "unable to update AlertReaction status")
 			return ctrl.Result{}, err
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: recheckAfter}, nil
+}
+
+// AlertResult is the outcome of processing a single alert
+type AlertResult struct {
+	// MatchedReactions are the namespaced names of the AlertReactions that matched the alert
+	MatchedReactions []string `json:"matchedReactions,omitempty"`
+
+	// MatchedGroups holds the index of the matcher group that matched the alert, by namespaced name of
+	// the AlertReactions with matcherGroups
+	MatchedGroups map[string]int `json:"matchedGroups,omitempty"`
+
+	// Jobs are the namespaced names of the jobs created for the alert
+	Jobs []string `json:"jobs,omitempty"`
+
+	// Errors are the errors of individual actions, which do not fail the alert as a whole
+	Errors []string `json:"errors,omitempty"`
 }
 
 // ProcessAlert creates jobs for all matching AlertReactions for the given alert
 func (r *AlertReactionReconciler) ProcessAlert(ctx context.Context, alertName string, alertData map[string]interface{}) error {
+	_, err := r.ProcessAlertWithResult(ctx, alertName, alertData)
+	return err
+}
+
+// ProcessAlertWithResult creates jobs for all matching AlertReactions for the given alert
+// like ProcessAlert, and reports the matched AlertReactions, the created jobs and action errors.
+func (r *AlertReactionReconciler) ProcessAlertWithResult(ctx context.Context, alertName string, alertData map[string]interface{}) (*AlertResult, error) {
+	return r.processAlert(ctx, alertName, alertData)
+}
+
+// ProcessNamespacedAlertWithResult is like ProcessAlertWithResult, but only considers the
+// AlertReactions in the given namespace
+func (r *AlertReactionReconciler) ProcessNamespacedAlertWithResult(ctx context.Context, namespace, alertName string, alertData map[string]interface{}) (*AlertResult, error) {
+	return r.processAlert(ctx, alertName, alertData, client.InNamespace(namespace))
+}
+
+func (r *AlertReactionReconciler) processAlert(ctx context.Context, alertName string, alertData map[string]interface{}, opts ...client.ListOption) (*AlertResult, error) {
 	logger := log.FromContext(ctx)
 
-	// Find all AlertReactions that match this alert
-	var alertReactionList alertreactionv1alpha1.AlertReactionList
-	if err := r.List(ctx, &alertReactionList); err != nil {
-		return fmt.Errorf("failed to list AlertReactions: %w", err)
+	// Find the AlertReactions for this alert
+	alertReactions, err := r.listAlertReactions(ctx, alertName, opts...)
+	if err != nil {
+		return nil, err
 	}
 
+	result := &AlertResult{}
+
+	// Resolved alerts only concern AlertReactions with onResolved actions or cancellable jobs
+	resolved := alertData["status"] == AlertStatusResolved
+
 	// Find all matching AlertReactions
 	var matchingAlertReactions []*alertreactionv1alpha1.AlertReaction
-	for i := range alertReactionList.Items {
-		alertReaction := &alertReactionList.Items[i]
-		if r.alertMatches(alertReaction, alertName, alertData) {
+	matchedGroups := make(map[*alertreactionv1alpha1.AlertReaction]int)
+	for i := range alertReactions {
+		alertReaction := &alertReactions[i]
+		// Group-mode AlertReactions are handled by ProcessGroup
+		if alertReaction.Spec.Mode == alertreactionv1alpha1.ReactionModeGroup {
+			continue
+		}
+		if resolved && !HandlesResolvedAlerts(alertReaction) {
+			continue
+		}
+		if matched, group := r.matchAlert(alertReaction, alertName, alertData); matched {
 			matchingAlertReactions = append(matchingAlertReactions, alertReaction)
+			if group >= 0 {
+				matchedGroups[alertReaction] = group
+			}
 		}
 	}
 
 	if len(matchingAlertReactions) == 0 {
 		logger.Info("No AlertReaction found for alert", "alertName", alertName)
-		return nil
+		return result, nil
 	}
 
-	logger.Info("Processing alert", "alertName", alertName, "matchingAlertReactions", len(matchingAlertReactions))
+	logger.Info("Processing alert", "alertName", alertName, "resolved", resolved, "matchingAlertReactions", len(matchingAlertReactions))
 
 	now := metav1.NewTime(time.Now())
 
 	// Process each matching AlertReaction
 	for _, targetAlertReaction := range matchingAlertReactions {
-		logger.Info("Processing AlertReaction", "name", targetAlertReaction.Name, "actionsCount", len(targetAlertReaction.Spec.Actions))
-
-		var jobRefs []alertreactionv1alpha1.JobReference
-
-		// Create a job for each action
-		for _, action := range targetAlertReaction.Spec.Actions {
-			job, err := r.createJobFromAction(ctx, targetAlertReaction, action, alertData)
-			if err != nil {
-				logger.Error(err, "failed to create job for action", "actionName", action.Name, "alertReaction", targetAlertReaction.Name)
-				continue
+		result.MatchedReactions = append(result.MatchedReactions, targetAlertReaction.Namespace+"/"+targetAlertReaction.Name)
+		if group, ok := matchedGroups[targetAlertReaction]; ok {
+			logger.Info("AlertReaction matched by matcher group", "alertReaction", targetAlertReaction.Name, "matcherGroup", group)
+			if result.MatchedGroups == nil {
+				result.MatchedGroups = make(map[string]int)
 			}
+			result.MatchedGroups[targetAlertReaction.Namespace+"/"+targetAlertReaction.Name] = group
+		}
 
-			if err := r.Create(ctx, job); err != nil {
-				logger.Error(err, "failed to create job", "jobName", job.Name, "alertReaction", targetAlertReaction.Name)
+		actions := targetAlertReaction.Spec.Actions
+		if resolved {
+			r.cancelJobsForResolvedAlert(ctx, targetAlertReaction, alertData)
+			if len(targetAlertReaction.Spec.OnResolved) == 0 {
 				continue
 			}
+			actions = targetAlertReaction.Spec.OnResolved
+		}
 
-			logger.Info("Created job for action", "jobName", job.Name, "actionName", action.Name, "alertReaction", targetAlertReaction.Name)
+		logger.Info("Processing AlertReaction", "name", targetAlertReaction.Name, "actionsCount", len(actions))
 
-			jobRefs = append(jobRefs, alertreactionv1alpha1.JobReference{
-				Name:       job.Name,
-				Namespace:  job.Namespace,
-				ActionName: action.Name,
-				CreatedAt:  now,
-			})
-		}
+		// Create a job for each action
+		jobRefs := r.runActions(ctx, targetAlertReaction, actions, alertData, now, result)
 
 		// Update AlertReaction status
+		if !resolved {
+			if err := recordLastAlert(targetAlertReaction, alertData); err != nil {
+				logger.Error(err, "failed to record last alert", "alertReaction", targetAlertReaction.Name)
+			}
+		}
 		targetAlertReaction.Status.LastTriggered = &now
 		targetAlertReaction.Status.TriggerCount++
 		targetAlertReaction.Status.LastJobsCreated = jobRefs
@@ -150,54 +217,151 @@ func (r *AlertReactionReconciler) ProcessAlert(ctx context.Context, alertName st
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
-// alertMatches checks if an AlertReaction matches the given alert based on alertName and matchers
-func (r *AlertReactionReconciler) alertMatches(alertReaction *alertreactionv1alpha1.AlertReaction, alertName string, alertData map[string]interface{}) bool {
-	// First check alertName match
-	if alertReaction.Spec.AlertName != alertName {
-		return false
+// runActions creates a job for each action with the given alert and records the created jobs and
+// action errors in result
+func (r *AlertReactionReconciler) runActions(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction, actions []alertreactionv1alpha1.Action, alertData map[string]interface{}, now metav1.Time, result *AlertResult) []alertreactionv1alpha1.JobReference {
+	logger := log.FromContext(ctx)
+
+	var jobRefs []alertreactionv1alpha1.JobReference
+	for _, action := range actions {
+		job, err := r.createJobFromAction(ctx, alertReaction, action, alertData)
+		if err != nil {
+			logger.Error(err, "failed to create job for action", "actionName", action.Name, "alertReaction", alertReaction.Name)
+			result.Errors = append(result.Errors, fmt.Sprintf("%s/%s action %s: %v", alertReaction.Namespace, alertReaction.Name, action.Name, err))
+			continue
+		}
+
+		if err := r.Create(ctx, job); err != nil {
+			logger.Error(err, "failed to create job", "jobName", job.Name, "alertReaction", alertReaction.Name)
+			result.Errors = append(result.Errors, fmt.Sprintf("%s/%s action %s: failed to create job: %v", alertReaction.Namespace, alertReaction.Name, action.Name, err))
+			continue
+		}
+		result.Jobs = append(result.Jobs, job.Namespace+"/"+job.Name)
+
+		logger.Info("Created job for action", "jobName", job.Name, "actionName", action.Name, "alertReaction", alertReaction.Name)
+
+		jobRefs = append(jobRefs, alertreactionv1alpha1.JobReference{
+			Name:       job.Name,
+			Namespace:  job.Namespace,
+			ActionName: action.Name,
+			CreatedAt:  now,
+		})
 	}
 
-	// If no matchers are specified, the AlertReaction matches (backward compatibility)
-	if len(alertReaction.Spec.Matchers) == 0 {
+	return jobRefs
+}
+
+// HandlesResolvedAlerts checks if an AlertReaction has anything to do when its alert resolves
+func HandlesResolvedAlerts(alertReaction *alertreactionv1alpha1.AlertReaction) bool {
+	if len(alertReaction.Spec.OnResolved) > 0 {
 		return true
 	}
+	for _, action := range alertReaction.Spec.Actions {
+		if action.CancelOnResolve != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// cancelJobsForResolvedAlert deletes or suspends the unfinished jobs created for the firing alert
+// with the same fingerprint, according to each action's cancelOnResolve policy
+func (r *AlertReactionReconciler) cancelJobsForResolvedAlert(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction, alertData map[string]interface{}) {
+	logger := log.FromContext(ctx)
+
+	fingerprint := alertFingerprint(alertData)
+	if fingerprint == "" {
+		return
+	}
+
+	for _, action := range alertReaction.Spec.Actions {
+		if action.CancelOnResolve == "" {
+			continue
+		}
+
+		var jobs batchv1.JobList
+		if err := r.List(ctx, &jobs, client.InNamespace(alertReaction.Namespace), client.MatchingLabels{
+			"karo/owner":             sanitizeLabelValue(alertReaction.Name),
+			"karo/action-name":       sanitizeLabelValue(action.Name),
+			"karo/alert-status":      AlertStatusFiring,
+			"karo/alert-fingerprint": sanitizeLabelValue(fingerprint),
+		}); err != nil {
+			logger.Error(err, "failed to list jobs to cancel", "actionName", action.Name, "alertReaction", alertReaction.Name)
+			continue
+		}
+
+		for i := range jobs.Items {
+			job := &jobs.Items[i]
+			if isJobFinished(job) {
+				continue
+			}
+
+			if err := r.cancelJob(ctx, job, action.CancelOnResolve); err != nil {
+				logger.Error(err, "failed to cancel job", "jobName", job.Name, "policy", action.CancelOnResolve, "alertReaction", alertReaction.Name)
+				continue
+			}
+
+			logger.Info("Cancelled job for resolved alert", "jobName", job.Name, "policy", action.CancelOnResolve, "actionName", action.Name, "alertReaction", alertReaction.Name)
+		}
+	}
+}
+
+// cancelJob applies a cancel policy to a single job
+func (r *AlertReactionReconciler) cancelJob(ctx context.Context, job *batchv1.Job, policy alertreactionv1alpha1.CancelPolicy) error {
+	switch policy {
+	case alertreactionv1alpha1.CancelPolicyDelete:
+		return client.IgnoreNotFound(r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+	default:
+		return fmt.Errorf("unknown cancel policy %q", policy)
+	}
+}
 
-	// All matchers must match for the AlertReaction to be triggered
-	for _, matcher := range alertReaction.Spec.Matchers {
-		if !r.evaluateMatcher(matcher, alertData) {
-			return false
+// isJobFinished checks if a job has completed or failed
+func isJobFinished(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return true
 		}
 	}
+	return false
+}
 
-	return true
+// alertMatches checks if an AlertReaction matches the given alert based on its alert names and matchers
+func (r *AlertReactionReconciler) alertMatches(alertReaction *alertreactionv1alpha1.AlertReaction, alertName string, alertData map[string]interface{}) bool {
+	matched, _ := r.matchAlert(alertReaction, alertName, alertData)
+	return matched
+}
+
+// matchAlert checks if an AlertReaction matches the given alert. It also returns the index of the
+// first matcher group that matched, or -1 if the AlertReaction has no matcher groups.
+func (r *AlertReactionReconciler) matchAlert(alertReaction *alertreactionv1alpha1.AlertReaction, alertName string, alertData map[string]interface{}) (bool, int) {
+	// First check alert name match
+	if !alertReaction.Spec.MatchesAlertName(alertName) {
+		return false, -1
+	}
+
+	// Restrict to the configured receivers, if any
+	if len(alertReaction.Spec.Receivers) > 0 && !containsString(alertReaction.Spec.Receivers, alertReceiver(alertData)) {
+		return false, -1
+	}
+
+	// If no matchers are specified, the AlertReaction matches (backward compatibility), unless it
+	// has no alert names either and would match every alert
+	if len(alertReaction.Spec.Matchers) == 0 && len(alertReaction.Spec.MatcherGroups) == 0 {
+		return len(alertReaction.Spec.AllAlertNames()) > 0, -1
+	}
+
+	// All matchers and the matchers of at least one group must match for the AlertReaction to be triggered
+	return r.matchers.get(alertReaction).match(r, alertData)
 }
 
 // evaluateMatcher evaluates a single matcher against alert data
 func (r *AlertReactionReconciler) evaluateMatcher(matcher alertreactionv1alpha1.AlertMatcher, alertData map[string]interface{}) bool {
-	// Get the value from alert data
-	actualValue, err := r.getMatcherValue(matcher.Name, alertData)
-	if err != nil {
-		// If we can't get the value, the matcher fails
-		return false
-	}
-
-	// Evaluate based on operator
-	switch matcher.Operator {
-	case alertreactionv1alpha1.MatchOperatorEqual:
-		return actualValue == matcher.Value
-	case alertreactionv1alpha1.MatchOperatorNotEqual:
-		return actualValue != matcher.Value
-	case alertreactionv1alpha1.MatchOperatorRegexMatch:
-		return r.regexMatch(actualValue, matcher.Value)
-	case alertreactionv1alpha1.MatchOperatorRegexNotMatch:
-		return !r.regexMatch(actualValue, matcher.Value)
-	default:
-		// Unknown operator
-		return false
-	}
+	compiled := compileMatcher(matcher)
+	return compiled.matches(r, alertData)
 }
 
 // getMatcherValue retrieves the value for a matcher from alert data
@@ -225,17 +389,12 @@ func (r *AlertReactionReconciler) getMatcherValue(name string, alertData map[str
 		return fmt.Sprintf("%v", value), nil
 	}
 
-	return "", fmt.Errorf("field %s not found", name)
-}
-
-// regexMatch performs regular expression matching
-func (r *AlertReactionReconciler) regexMatch(value, pattern string) bool {
-	matched, err := regexp.MatchString(pattern, value)
-	if err != nil {
-		// Invalid regex pattern, return false
-		return false
+	// Handle nested fields like "notification.commonLabels.team"
+	if strings.Contains(name, ".") {
+		return r.getNestedField(alertData, name)
 	}
-	return matched
+
+	return "", fmt.Errorf("field %s not found", name)
 }
 
 func (r *AlertReactionReconciler) createJobFromAction(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction, action alertreactionv1alpha1.Action, alertData map[string]interface{}) (*batchv1.Job, error) {
@@ -272,10 +431,18 @@ func (r *AlertReactionReconciler) createJobFromAction(ctx context.Context, alert
 			Requests: make(corev1.ResourceList),
 		}
 		for k, v := range action.Resources.Limits {
-			resources.Limits[corev1.ResourceName(k)] = parseQuantity(v)
+			quantity, err := resource.ParseQuantity(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid resource limit %s=%q: %w", k, v, err)
+			}
+			resources.Limits[corev1.ResourceName(k)] = quantity
 		}
 		for k, v := range action.Resources.Requests {
-			resources.Requests[corev1.ResourceName(k)] = parseQuantity(v)
+			quantity, err := resource.ParseQuantity(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid resource request %s=%q: %w", k, v, err)
+			}
+			resources.Requests[corev1.ResourceName(k)] = quantity
 		}
 	}
 
@@ -288,20 +455,6 @@ func (r *AlertReactionReconciler) createJobFromAction(ctx context.Context, alert
 	// Convert volume mounts
 	volumeMounts := r.convertVolumeMounts(action.VolumeMounts)
 
-	// Sanitize label values to match Kubernetes requirements: (([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?
-	sanitizeLabelValue := func(val string) string {
-		// Replace invalid characters with '-'
-		if len(val) > 63 {
-			val = val[:63]
-		}
-		val = regexp.MustCompile(`[^A-Za-z0-9_.-]`).ReplaceAllString(val, "-")
-		// Ensure starts/ends with alphanumeric
-		val = regexp.MustCompile(`^[^A-Za-z0-9]+`).ReplaceAllString(val, "")
-		val = regexp.MustCompile(`[^A-Za-z0-9]+$`).ReplaceAllString(val, "")
-		// Truncate to 63 chars (Kubernetes label value max length)
-		return val
-	}
-
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      jobName,
@@ -309,9 +462,11 @@ func (r *AlertReactionReconciler) createJobFromAction(ctx context.Context, alert
 			Labels: map[string]string{
 				"app.kubernetes.io/name":      "karo-job",
 				"app.kubernetes.io/component": "job",
-				"karo/alert-name":             sanitizeLabelValue(alertReaction.Spec.AlertName),
+				"karo/alert-name":             sanitizeLabelValue(jobAlertName(alertReaction, alertData)),
 				"karo/action-name":            sanitizeLabelValue(action.Name),
 				"karo/owner":                  sanitizeLabelValue(alertReaction.Name),
+				"karo/alert-status":           sanitizeLabelValue(alertStatus(alertData)),
+				"karo/alert-fingerprint":      sanitizeLabelValue(alertFingerprint(alertData)),
 			},
 			OwnerReferences: []metav1.OwnerReference{
 				{
@@ -345,6 +500,14 @@ func (r *AlertReactionReconciler) createJobFromAction(ctx context.Context, alert
 		},
 	}
 
+	// Record manual triggers and who requested them
+	if trigger, ok := alertData["trigger"].(string); ok && trigger != "" {
+		job.Labels["karo/trigger"] = sanitizeLabelValue(trigger)
+	}
+	if triggeredBy, ok := alertData["triggeredBy"].(string); ok && triggeredBy != "" {
+		job.Annotations = map[string]string{TriggeredByAnnotation: triggeredBy}
+	}
+
 	return job, nil
 }
 
@@ -509,9 +672,11 @@ func (r *AlertReactionReconciler) convertVolumes(volumes []alertreactionv1alpha1
 				emptyDir.Medium = corev1.StorageMedium(vol.EmptyDir.Medium)
 			}
 			if vol.EmptyDir.SizeLimit != "" {
-				if sizeLimit, err := resource.ParseQuantity(vol.EmptyDir.SizeLimit); err == nil {
-					emptyDir.SizeLimit = &sizeLimit
+				sizeLimit, err := resource.ParseQuantity(vol.EmptyDir.SizeLimit)
+				if err != nil {
+					return nil, fmt.Errorf("volume %s has an invalid sizeLimit %q: %w", vol.Name, vol.EmptyDir.SizeLimit, err)
 				}
+				emptyDir.SizeLimit = &sizeLimit
 			}
 			k8sVol.EmptyDir = emptyDir
 		} else if vol.PersistentVolumeClaim != nil {
@@ -557,6 +722,10 @@ func (r *AlertReactionReconciler) convertVolumeMounts(volumeMounts []alertreacti
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *AlertReactionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := setupIndexes(context.Background(), mgr); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&alertreactionv1alpha1.AlertReaction{}).
 		Owns(&batchv1.Job{}).
@@ -564,13 +733,94 @@ func (r *AlertReactionReconciler) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 // Helper functions
-func int32Ptr(i int32) *int32 {
-	return &i
+
+// sanitizeLabelValue makes a value match Kubernetes label requirements: (([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?
+func sanitizeLabelValue(val string) string {
+	// Replace invalid characters with '-'
+	if len(val) > 63 {
+		val = val[:63]
+	}
+	val = regexp.MustCompile(`[^A-Za-z0-9_.-]`).ReplaceAllString(val, "-")
+	// Ensure starts/ends with alphanumeric
+	val = regexp.MustCompile(`^[^A-Za-z0-9]+`).ReplaceAllString(val, "")
+	val = regexp.MustCompile(`[^A-Za-z0-9]+$`).ReplaceAllString(val, "")
+	// Truncate to 63 chars (Kubernetes label value max length)
+	return val
 }
 
-func parseQuantity(s string) resource.Quantity {
-	// Simple implementation - in production, use resource.ParseQuantity
-	return resource.MustParse(s)
+// LabelsFingerprint derives a stable fingerprint from a label set. It uses the same hash as
+// Alertmanager, so fingerprints match the ones Alertmanager reports for the same labels.
+func LabelsFingerprint(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := fnv.New64a()
+	for _, name := range names {
+		_, _ = hash.Write([]byte(name))
+		_, _ = hash.Write([]byte{0xff})
+		_, _ = hash.Write([]byte(labels[name]))
+		_, _ = hash.Write([]byte{0xff})
+	}
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+func alertFingerprint(alertData map[string]interface{}) string {
+	if fingerprint, ok := alertData["fingerprint"].(string); ok {
+		return fingerprint
+	}
+	return ""
+}
+
+func alertReceiver(alertData map[string]interface{}) string {
+	if receiver, ok := alertData["receiver"].(string); ok {
+		return receiver
+	}
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// primaryAlertName is the name of alerts created for an AlertReaction by manual and query triggers:
+// its first alert name, or its own name if it reacts to alerts with any name
+func primaryAlertName(alertReaction *alertreactionv1alpha1.AlertReaction) string {
+	if names := alertReaction.Spec.AllAlertNames(); len(names) > 0 {
+		return names[0]
+	}
+	return alertReaction.Name
+}
+
+// jobAlertName returns the name of the alert a job is created for, taken from the labels of the alert
+// or the group labels of a notification group
+func jobAlertName(alertReaction *alertreactionv1alpha1.AlertReaction, alertData map[string]interface{}) string {
+	for _, key := range []string{"labels", "groupLabels", "commonLabels"} {
+		if labels, ok := alertData[key].(map[string]interface{}); ok {
+			if alertName, ok := labels["alertname"].(string); ok && alertName != "" {
+				return alertName
+			}
+		}
+	}
+	return primaryAlertName(alertReaction)
+}
+
+func alertStatus(alertData map[string]interface{}) string {
+	if status, ok := alertData["status"].(string); ok && status != "" {
+		return status
+	}
+	return AlertStatusFiring
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
 }
 
 func generateRandomString(length int) (string, error) {
@@ -23,7 +23,11 @@ func setupTestEmpty() (*AlertReactionReconciler, client.Client) {
 	_ = alertreactionv1alpha1.AddToScheme(s)
 	_ = batchv1.AddToScheme(s)
 
-	fakeClient := fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&alertreactionv1alpha1.AlertReaction{}).Build()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithStatusSubresource(&alertreactionv1alpha1.AlertReaction{}).
+		WithIndex(&alertreactionv1alpha1.AlertReaction{}, AlertNameField, AlertNameIndexer).
+		Build()
 
 	reconciler := &AlertReactionReconciler{
 		Client: fakeClient,
@@ -61,6 +65,7 @@ func setupTestWithAlertReaction() (*AlertReactionReconciler, client.Client) {
 		WithScheme(s).
 		WithObjects(alertReaction).
 		WithStatusSubresource(&alertreactionv1alpha1.AlertReaction{}).
+		WithIndex(&alertreactionv1alpha1.AlertReaction{}, AlertNameField, AlertNameIndexer).
 		Build()
 
 	reconciler := &AlertReactionReconciler{
@@ -299,6 +304,127 @@ func TestAlertReactionReconciler_ProcessAlertNoMatch(t *testing.T) {
 	}
 }
 
+func TestAlertReactionReconciler_ProcessResolvedAlert(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	alertReactions := []*alertreactionv1alpha1.AlertReaction{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "with-on-resolved", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				AlertName: "TestAlert",
+				Actions: []alertreactionv1alpha1.Action{
+					{Name: "scale-up", Image: "busybox:latest"},
+				},
+				OnResolved: []alertreactionv1alpha1.Action{
+					{Name: "scale-down", Image: "busybox:latest"},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "without-on-resolved", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				AlertName: "TestAlert",
+				Actions: []alertreactionv1alpha1.Action{
+					{Name: "notify", Image: "busybox:latest"},
+				},
+			},
+		},
+	}
+	for _, alertReaction := range alertReactions {
+		if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+			t.Fatalf("Failed to create AlertReaction: %v", err)
+		}
+	}
+
+	alertData := map[string]interface{}{
+		"status": "resolved",
+		"labels": map[string]interface{}{
+			"alertname": "TestAlert",
+		},
+	}
+
+	if err := reconciler.ProcessAlert(context.TODO(), "TestAlert", alertData); err != nil {
+		t.Fatalf("ProcessAlert failed: %v", err)
+	}
+
+	// Only the onResolved action of the first AlertReaction runs
+	var jobs batchv1.JobList
+	if err := fakeClient.List(context.TODO(), &jobs, client.InNamespace("default")); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Fatalf("Expected 1 job, got %d", len(jobs.Items))
+	}
+
+	job := jobs.Items[0]
+	if job.Labels["karo/action-name"] != "scale-down" {
+		t.Errorf("Expected job for action scale-down, got %s", job.Labels["karo/action-name"])
+	}
+	if job.Labels["karo/alert-status"] != "resolved" {
+		t.Errorf("Expected karo/alert-status label resolved, got %s", job.Labels["karo/alert-status"])
+	}
+}
+
+func TestAlertReactionReconciler_CancelOnResolve(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "remediation", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "TestAlert",
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "drain", Image: "busybox:latest", CancelOnResolve: alertreactionv1alpha1.CancelPolicyDelete},
+				{Name: "notify", Image: "busybox:latest"},
+			},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	alert := func(status, fingerprint string) map[string]interface{} {
+		return map[string]interface{}{
+			"status":      status,
+			"fingerprint": fingerprint,
+			"labels":      map[string]interface{}{"alertname": "TestAlert"},
+		}
+	}
+
+	// Two different alerts fire, each creating one job per action
+	for _, fingerprint := range []string{"aaa111", "bbb222"} {
+		if err := reconciler.ProcessAlert(context.TODO(), "TestAlert", alert(AlertStatusFiring, fingerprint)); err != nil {
+			t.Fatalf("ProcessAlert failed: %v", err)
+		}
+	}
+
+	if err := reconciler.ProcessAlert(context.TODO(), "TestAlert", alert(AlertStatusResolved, "aaa111")); err != nil {
+		t.Fatalf("ProcessAlert failed: %v", err)
+	}
+
+	var jobs batchv1.JobList
+	if err := fakeClient.List(context.TODO(), &jobs, client.InNamespace("default")); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+
+	remaining := make(map[string]batchv1.Job)
+	for _, job := range jobs.Items {
+		remaining[job.Labels["karo/alert-fingerprint"]+"/"+job.Labels["karo/action-name"]] = job
+	}
+
+	if len(jobs.Items) != 3 {
+		t.Errorf("Expected 3 jobs after the drain job was deleted, got %d", len(jobs.Items))
+	}
+	if _, exists := remaining["aaa111/drain"]; exists {
+		t.Error("Expected drain job of the resolved alert to be deleted")
+	}
+	if _, exists := remaining["aaa111/notify"]; !exists {
+		t.Error("Expected notify job without cancelOnResolve to be left alone")
+	}
+	if _, exists := remaining["bbb222/drain"]; !exists {
+		t.Error("Expected drain job of the still firing alert to remain")
+	}
+}
+
 func TestGetAlertFieldValue(t *testing.T) {
 	reconciler, _ := setupTestEmpty()
 
@@ -452,6 +578,28 @@ func TestCreateJobFromAction(t *testing.T) {
 	}
 }
 
+func TestCreateJobFromAction_InvalidResources(t *testing.T) {
+	reconciler, _ := setupTestEmpty()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-alert-reaction", Namespace: "default"},
+		Spec:       alertreactionv1alpha1.AlertReactionSpec{AlertName: "TestAlert"},
+	}
+	action := alertreactionv1alpha1.Action{
+		Name:  "test-action",
+		Image: "busybox:latest",
+		Resources: &alertreactionv1alpha1.ResourceRequirements{
+			Limits: map[string]string{"memory": "lots"},
+		},
+	}
+
+	// An invalid quantity is reported instead of crashing the operator
+	_, err := reconciler.createJobFromAction(context.TODO(), alertReaction, action, map[string]interface{}{"status": "firing"})
+	if err == nil || !contains(err.Error(), "invalid resource limit memory") {
+		t.Errorf("Expected an invalid resource limit error, got %v", err)
+	}
+}
+
 func TestCreateJobFromActionWithVolumes(t *testing.T) {
 	reconciler, _ := setupTestEmpty()
 
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+// Reasons of the conditions reported in the status of AlertReactions
+const (
+	ReasonReady              = "AlertReactionReady"
+	ReasonSpecValid          = "SpecValid"
+	ReasonInvalidSpec        = "InvalidSpec"
+	ReasonReferencesResolved = "ReferencesResolved"
+	ReasonReferencesNotFound = "ReferencesNotFound"
+	ReasonReferenceCheckFail = "ReferenceCheckFailed"
+)
+
+// referenceRecheckInterval is how often unresolved references are checked again, since the
+// referenced objects are not watched
+const referenceRecheckInterval = time.Minute
+
+// reference is an object referenced by an AlertReaction
+type reference struct {
+	kind string
+	name string
+
+	// keys are the keys of ConfigMaps and Secrets that must be present
+	keys []string
+}
+
+// updateConditions validates an AlertReaction and resolves its references, and reports the result
+// in the Valid, ReferencesResolved and Ready conditions. It returns whether the status changed and
+// when unresolved references should be checked again.
+func (r *AlertReactionReconciler) updateConditions(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction) (bool, time.Duration) {
+	generation := alertReaction.Generation
+	var recheckAfter time.Duration
+
+	valid := metav1.Condition{
+		Type:               alertreactionv1alpha1.ConditionValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonSpecValid,
+		Message:            "AlertReaction spec is valid",
+		ObservedGeneration: generation,
+	}
+	if errs := alertReaction.Spec.Validate(field.NewPath("spec")); len(errs) > 0 {
+		valid.Status = metav1.ConditionFalse
+		valid.Reason = ReasonInvalidSpec
+		valid.Message = errs.ToAggregate().Error()
+	}
+
+	resolved := metav1.Condition{
+		Type:               alertreactionv1alpha1.ConditionReferencesResolved,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonReferencesResolved,
+		Message:            "All referenced objects exist",
+		ObservedGeneration: generation,
+	}
+	if missing, err := r.resolveReferences(ctx, alertReaction); err != nil {
+		resolved.Status = metav1.ConditionUnknown
+		resolved.Reason = ReasonReferenceCheckFail
+		resolved.Message = err.Error()
+		recheckAfter = referenceRecheckInterval
+	} else if len(missing) > 0 {
+		resolved.Status = metav1.ConditionFalse
+		resolved.Reason = ReasonReferencesNotFound
+		resolved.Message = strings.Join(missing, "; ")
+		recheckAfter = referenceRecheckInterval
+	}
+
+	ready := metav1.Condition{
+		Type:               alertreactionv1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonReady,
+		Message:            "AlertReaction is ready to process alerts",
+		ObservedGeneration: generation,
+	}
+	switch {
+	case valid.Status != metav1.ConditionTrue:
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = valid.Reason
+		ready.Message = "AlertReaction spec is invalid: " + valid.Message
+	case resolved.Status != metav1.ConditionTrue:
+		ready.Status = resolved.Status
+		ready.Reason = resolved.Reason
+		ready.Message = "AlertReaction references could not be resolved: " + resolved.Message
+	}
+
+	changed := alertReaction.Status.ObservedGeneration != generation
+	alertReaction.Status.ObservedGeneration = generation
+	for _, condition := range []metav1.Condition{ready, valid, resolved} {
+		if countConditions(alertReaction.Status.Conditions, condition.Type) > 1 {
+			// Older versions appended a condition on every change
+			meta.RemoveStatusCondition(&alertReaction.Status.Conditions, condition.Type)
+			changed = true
+		}
+		if meta.SetStatusCondition(&alertReaction.Status.Conditions, condition) {
+			changed = true
+		}
+	}
+	return changed, recheckAfter
+}
+
+// resolveReferences checks that the objects referenced by an AlertReaction exist. It returns a
+// description of each missing object or key, and an error if the objects could not be read.
+// Optional ConfigMap and Secret references are not checked.
+func (r *AlertReactionReconciler) resolveReferences(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction) ([]string, error) {
+	var missing []string
+	for _, ref := range references(alertReaction) {
+		key := types.NamespacedName{Namespace: alertReaction.Namespace, Name: ref.name}
+
+		var obj client.Object
+		switch ref.kind {
+		case "ConfigMap":
+			obj = &corev1.ConfigMap{}
+		case "Secret":
+			obj = &corev1.Secret{}
+		case "ServiceAccount":
+			obj = &corev1.ServiceAccount{}
+		case "PersistentVolumeClaim":
+			obj = &corev1.PersistentVolumeClaim{}
+		}
+
+		if err := r.Get(ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				missing = append(missing, fmt.Sprintf("%s %q not found", ref.kind, ref.name))
+				continue
+			}
+			return nil, fmt.Errorf("failed to get %s %q: %w", ref.kind, ref.name, err)
+		}
+
+		for _, k := range ref.keys {
+			var found bool
+			switch o := obj.(type) {
+			case *corev1.ConfigMap:
+				_, found = o.Data[k]
+				if !found {
+					_, found = o.BinaryData[k]
+				}
+			case *corev1.Secret:
+				_, found = o.Data[k]
+			}
+			if !found {
+				missing = append(missing, fmt.Sprintf("%s %q has no key %q", ref.kind, ref.name, k))
+			}
+		}
+	}
+	return missing, nil
+}
+
+// references returns the objects referenced by an AlertReaction, in the order they appear in the spec
+func references(alertReaction *alertreactionv1alpha1.AlertReaction) []reference {
+	var refs []reference
+	index := make(map[string]int)
+	add := func(kind, name, key string) {
+		id := kind + "/" + name
+		i, ok := index[id]
+		if !ok {
+			i = len(refs)
+			index[id] = i
+			refs = append(refs, reference{kind: kind, name: name})
+		}
+		if key != "" {
+			for _, k := range refs[i].keys {
+				if k == key {
+					return
+				}
+			}
+			refs[i].keys = append(refs[i].keys, key)
+		}
+	}
+	optional := func(b *bool) bool {
+		return b != nil && *b
+	}
+
+	for _, volume := range alertReaction.Spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil && !optional(volume.ConfigMap.Optional):
+			add("ConfigMap", volume.ConfigMap.Name, "")
+		case volume.Secret != nil && !optional(volume.Secret.Optional):
+			add("Secret", volume.Secret.SecretName, "")
+		case volume.PersistentVolumeClaim != nil:
+			add("PersistentVolumeClaim", volume.PersistentVolumeClaim.ClaimName, "")
+		}
+	}
+
+	for _, actions := range [][]alertreactionv1alpha1.Action{alertReaction.Spec.Actions, alertReaction.Spec.OnResolved} {
+		for _, action := range actions {
+			if action.ServiceAccount != "" {
+				add("ServiceAccount", action.ServiceAccount, "")
+			}
+			for _, envVar := range action.Env {
+				if envVar.Value != "" || envVar.ValueFrom == nil {
+					continue
+				}
+				if ref := envVar.ValueFrom.ConfigMapKeyRef; ref != nil && !optional(ref.Optional) {
+					add("ConfigMap", ref.Name, ref.Key)
+				}
+				if ref := envVar.ValueFrom.SecretKeyRef; ref != nil && !optional(ref.Optional) {
+					add("Secret", ref.Name, ref.Key)
+				}
+			}
+		}
+	}
+
+	return refs
+}
+
+func countConditions(conditions []metav1.Condition, conditionType string) int {
+	count := 0
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+func TestAlertReactionReconciler_Conditions(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-reaction", Namespace: "default", Generation: 3},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "DatabaseDown",
+			Volumes: []alertreactionv1alpha1.Volume{
+				{Name: "config", VolumeSource: alertreactionv1alpha1.VolumeSource{
+					ConfigMap: &alertreactionv1alpha1.ConfigMapVolumeSource{Name: "backup-config"},
+				}},
+			},
+			Actions: []alertreactionv1alpha1.Action{
+				{
+					Name:           "backup",
+					Image:          "postgres:15",
+					ServiceAccount: "backup",
+					Env: []alertreactionv1alpha1.EnvVar{
+						{Name: "PASSWORD", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+							SecretKeyRef: &alertreactionv1alpha1.SecretKeySelector{Name: "db", Key: "password"},
+						}},
+					},
+					VolumeMounts: []alertreactionv1alpha1.VolumeMount{{Name: "config", MountPath: "/config"}},
+				},
+			},
+		},
+		Status: alertreactionv1alpha1.AlertReactionStatus{
+			// Conditions appended by older versions
+			Conditions: []metav1.Condition{
+				{Type: "Ready", Status: metav1.ConditionTrue, Reason: "AlertReactionReady", LastTransitionTime: metav1.Now()},
+				{Type: "Ready", Status: metav1.ConditionTrue, Reason: "AlertReactionReady", LastTransitionTime: metav1.Now()},
+			},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	key := types.NamespacedName{Name: "backup-reaction", Namespace: "default"}
+	reconcile := func() (ctrl.Result, *alertreactionv1alpha1.AlertReaction) {
+		t.Helper()
+		result, err := reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("Reconcile failed: %v", err)
+		}
+		var updated alertreactionv1alpha1.AlertReaction
+		if err := fakeClient.Get(context.TODO(), key, &updated); err != nil {
+			t.Fatalf("Failed to get AlertReaction: %v", err)
+		}
+		return result, &updated
+	}
+	condition := func(alertReaction *alertreactionv1alpha1.AlertReaction, conditionType string) *metav1.Condition {
+		t.Helper()
+		c := meta.FindStatusCondition(alertReaction.Status.Conditions, conditionType)
+		if c == nil {
+			t.Fatalf("Expected a %s condition, got %+v", conditionType, alertReaction.Status.Conditions)
+		}
+		return c
+	}
+
+	// Missing references
+	result, updated := reconcile()
+	if len(updated.Status.Conditions) != 3 {
+		t.Errorf("Expected the Ready, Valid and ReferencesResolved conditions, got %+v", updated.Status.Conditions)
+	}
+	if updated.Status.ObservedGeneration != 3 {
+		t.Errorf("Expected observed generation 3, got %d", updated.Status.ObservedGeneration)
+	}
+	if c := condition(updated, alertreactionv1alpha1.ConditionValid); c.Status != metav1.ConditionTrue || c.ObservedGeneration != 3 {
+		t.Errorf("Expected the spec to be valid, got %+v", c)
+	}
+	resolved := condition(updated, alertreactionv1alpha1.ConditionReferencesResolved)
+	if resolved.Status != metav1.ConditionFalse || resolved.Reason != ReasonReferencesNotFound {
+		t.Errorf("Expected unresolved references, got %+v", resolved)
+	}
+	for _, missing := range []string{`ConfigMap "backup-config" not found`, `ServiceAccount "backup" not found`, `Secret "db" not found`} {
+		if !strings.Contains(resolved.Message, missing) {
+			t.Errorf("Expected %q in the message, got %q", missing, resolved.Message)
+		}
+	}
+	if c := condition(updated, alertreactionv1alpha1.ConditionReady); c.Status != metav1.ConditionFalse || c.Reason != ReasonReferencesNotFound {
+		t.Errorf("Expected the AlertReaction not to be ready, got %+v", c)
+	}
+	if result.RequeueAfter != referenceRecheckInterval {
+		t.Errorf("Expected references to be checked again in %v, got %v", referenceRecheckInterval, result.RequeueAfter)
+	}
+
+	// Secrets must contain the referenced keys
+	for _, obj := range []client.Object{
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "backup-config", Namespace: "default"}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}, Data: map[string][]byte{"user": []byte("postgres")}},
+	} {
+		if err := fakeClient.Create(context.TODO(), obj); err != nil {
+			t.Fatalf("Failed to create %s: %v", obj.GetName(), err)
+		}
+	}
+	_, updated = reconcile()
+	if c := condition(updated, alertreactionv1alpha1.ConditionReferencesResolved); c.Message != `Secret "db" has no key "password"` {
+		t.Errorf("Expected the missing key to be reported, got %q", c.Message)
+	}
+
+	// Resolved references
+	var secret corev1.Secret
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "db", Namespace: "default"}, &secret); err != nil {
+		t.Fatalf("Failed to get Secret: %v", err)
+	}
+	secret.Data["password"] = []byte("secret")
+	if err := fakeClient.Update(context.TODO(), &secret); err != nil {
+		t.Fatalf("Failed to update Secret: %v", err)
+	}
+	result, updated = reconcile()
+	if c := condition(updated, alertreactionv1alpha1.ConditionReady); c.Status != metav1.ConditionTrue || c.Reason != ReasonReady {
+		t.Errorf("Expected the AlertReaction to be ready, got %+v", c)
+	}
+	if result.RequeueAfter != 0 {
+		t.Errorf("Expected no requeue once references resolve, got %v", result.RequeueAfter)
+	}
+
+	// Invalid spec
+	updated.Spec.Matchers = []alertreactionv1alpha1.AlertMatcher{
+		{Name: "instance", Operator: alertreactionv1alpha1.MatchOperatorRegexMatch, Value: "[db"},
+	}
+	updated.Generation = 4
+	if err := fakeClient.Update(context.TODO(), updated); err != nil {
+		t.Fatalf("Failed to update AlertReaction: %v", err)
+	}
+	_, updated = reconcile()
+	valid := condition(updated, alertreactionv1alpha1.ConditionValid)
+	if valid.Status != metav1.ConditionFalse || valid.Reason != ReasonInvalidSpec || !strings.Contains(valid.Message, "spec.matchers[0].value") {
+		t.Errorf("Expected the invalid matcher to be reported, got %+v", valid)
+	}
+	ready := condition(updated, alertreactionv1alpha1.ConditionReady)
+	if ready.Status != metav1.ConditionFalse || ready.Reason != ReasonInvalidSpec || ready.ObservedGeneration != 4 {
+		t.Errorf("Expected the AlertReaction not to be ready, got %+v", ready)
+	}
+}
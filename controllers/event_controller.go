@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	eventsv1 "k8s.io/api/events/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// EventReconciler converts Kubernetes Events into alerts processed by AlertReactions. The event
+// reason becomes the alertname and the fields of the regarding object become labels.
+// Events recorded through the core/v1 API are served by events.k8s.io/v1 as well, so both are covered.
+type EventReconciler struct {
+	client.Client
+
+	// Alerts processes the alerts created from events
+	Alerts *AlertReactionReconciler
+
+	// Types restricts the converted events to the given types, e.g. "Warning". All types are converted when empty.
+	Types []string
+
+	// startTime excludes events last observed before the operator started
+	startTime time.Time
+
+	mu sync.Mutex
+	// seen holds the last processed occurrence of each event
+	seen map[types.NamespacedName]seenEvent
+}
+
+// seenEvent identifies a processed occurrence of an event
+type seenEvent struct {
+	uid   types.UID
+	count int32
+}
+
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=get;list;watch
+
+// Reconcile processes every new occurrence of an Event as an alert
+func (r *EventReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var event eventsv1.Event
+	if err := r.Get(ctx, req.NamespacedName, &event); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !r.typeWatched(event.Type) || eventLastObserved(&event).Before(r.startTime) {
+		return ctrl.Result{}, nil
+	}
+
+	occurrence := seenEvent{uid: event.UID, count: eventCount(&event)}
+	if !r.markSeen(req.NamespacedName, occurrence) {
+		// Already processed this occurrence, e.g. on a resync or metadata update
+		return ctrl.Result{}, nil
+	}
+
+	// Most events concern no AlertReaction, so avoid processing them as alerts
+	reacting, err := r.hasAlertReaction(ctx, event.Reason)
+	if err != nil {
+		r.unmarkSeen(req.NamespacedName, occurrence)
+		return ctrl.Result{}, err
+	}
+	if !reacting {
+		return ctrl.Result{}, nil
+	}
+
+	logger.Info("Processing event as alert", "reason", event.Reason, "kind", event.Regarding.Kind, "name", event.Regarding.Name, "count", occurrence.count)
+
+	if err := r.Alerts.ProcessAlert(ctx, event.Reason, eventToAlert(&event, occurrence.count)); err != nil {
+		r.unmarkSeen(req.NamespacedName, occurrence)
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// hasAlertReaction checks if any AlertReaction reacts to alerts with the given name
+func (r *EventReconciler) hasAlertReaction(ctx context.Context, alertName string) (bool, error) {
+	alertReactions, err := r.Alerts.listAlertReactions(ctx, alertName)
+	if err != nil {
+		return false, err
+	}
+	return len(alertReactions) > 0, nil
+}
+
+func (r *EventReconciler) typeWatched(eventType string) bool {
+	return len(r.Types) == 0 || containsString(r.Types, eventType)
+}
+
+// markSeen records an occurrence of an event. It reports false if the occurrence was already seen.
+// A recreated event with the same name has a new UID and counts as new.
+func (r *EventReconciler) markSeen(name types.NamespacedName, occurrence seenEvent) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.seen == nil {
+		r.seen = make(map[types.NamespacedName]seenEvent)
+	}
+	if seen, ok := r.seen[name]; ok && seen.uid == occurrence.uid && seen.count >= occurrence.count {
+		return false
+	}
+	r.seen[name] = occurrence
+	return true
+}
+
+// unmarkSeen forgets an occurrence that could not be processed, so it is retried
+func (r *EventReconciler) unmarkSeen(name types.NamespacedName, occurrence seenEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.seen[name] == occurrence {
+		delete(r.seen, name)
+	}
+}
+
+// forget drops a deleted event
+func (r *EventReconciler) forget(name types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.seen, name)
+}
+
+// eventToAlert converts an Event into alert data, as the webhook server does for AlertManager alerts
+func eventToAlert(event *eventsv1.Event, count int32) map[string]interface{} {
+	regarding := event.Regarding
+	namespace := regarding.Namespace
+	if namespace == "" {
+		namespace = event.Namespace
+	}
+
+	labels := map[string]string{
+		"alertname":            event.Reason,
+		"type":                 event.Type,
+		"kind":                 regarding.Kind,
+		"name":                 regarding.Name,
+		"namespace":            namespace,
+		"reporting_controller": event.ReportingController,
+	}
+	// Like kube-state-metrics alerts, name the regarding object by its kind, e.g. pod="api-1"
+	if kindLabel := strings.ToLower(regarding.Kind); kindLabel != "" && regarding.Name != "" {
+		if _, exists := labels[kindLabel]; !exists {
+			labels[kindLabel] = regarding.Name
+		}
+	}
+
+	alertData := map[string]interface{}{
+		"status":      AlertStatusFiring,
+		"startsAt":    eventFirstObserved(event).UTC().Format(time.RFC3339),
+		"fingerprint": string(event.UID),
+		"count":       count,
+	}
+
+	for k, v := range labels {
+		if v == "" {
+			delete(labels, k)
+		}
+	}
+	setAlertMetadata(alertData, labels, map[string]string{"message": event.Note})
+
+	return alertData
+}
+
+// eventCount returns the number of occurrences of an event
+func eventCount(event *eventsv1.Event) int32 {
+	if event.Series != nil {
+		return event.Series.Count
+	}
+	if event.DeprecatedCount > 0 {
+		return event.DeprecatedCount
+	}
+	return 1
+}
+
+// eventFirstObserved returns when an event first occurred
+func eventFirstObserved(event *eventsv1.Event) time.Time {
+	switch {
+	case !event.DeprecatedFirstTimestamp.IsZero():
+		return event.DeprecatedFirstTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+// eventLastObserved returns when an event last occurred
+func eventLastObserved(event *eventsv1.Event) time.Time {
+	switch {
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.DeprecatedLastTimestamp.IsZero():
+		return event.DeprecatedLastTimestamp.Time
+	default:
+		return eventFirstObserved(event)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *EventReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.startTime = time.Now()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("event").
+		For(&eventsv1.Event{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(object client.Object) bool {
+			event, ok := object.(*eventsv1.Event)
+			return !ok || r.typeWatched(event.Type)
+		})).
+		Complete(r)
+}
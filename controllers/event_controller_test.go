@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+func TestEventReconciler_ProcessesNewOccurrences(t *testing.T) {
+	alertReconciler, fakeClient := setupTestEmpty()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "backoff-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "BackOff",
+			Matchers: []alertreactionv1alpha1.AlertMatcher{
+				{Name: "kind", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "Pod"},
+			},
+			Actions: []alertreactionv1alpha1.Action{
+				{
+					Name:  "collect-logs",
+					Image: "busybox:latest",
+					Env: []alertreactionv1alpha1.EnvVar{
+						{Name: "POD", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+							AlertRef: &alertreactionv1alpha1.AlertFieldSelector{FieldPath: "labels.pod"},
+						}},
+					},
+				},
+			},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	reconciler := &EventReconciler{
+		Client:    fakeClient,
+		Alerts:    alertReconciler,
+		Types:     []string{corev1.EventTypeWarning},
+		startTime: time.Now().Add(-time.Minute),
+	}
+
+	now := metav1.NewMicroTime(time.Now())
+	event := &eventsv1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1.backoff", Namespace: "default", UID: "event-uid"},
+		EventTime:  now,
+		Reason:     "BackOff",
+		Type:       corev1.EventTypeWarning,
+		Note:       "Back-off restarting failed container",
+		Regarding:  corev1.ObjectReference{Kind: "Pod", Name: "api-1", Namespace: "default"},
+	}
+	staleEvent := &eventsv1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-2.backoff", Namespace: "default", UID: "stale-uid"},
+		EventTime:  metav1.NewMicroTime(time.Now().Add(-time.Hour)),
+		Reason:     "BackOff",
+		Type:       corev1.EventTypeWarning,
+		Regarding:  corev1.ObjectReference{Kind: "Pod", Name: "api-2", Namespace: "default"},
+	}
+	for _, e := range []*eventsv1.Event{event, staleEvent} {
+		if err := fakeClient.Create(context.TODO(), e); err != nil {
+			t.Fatalf("Failed to create Event: %v", err)
+		}
+	}
+
+	reconcile := func(name string) {
+		t.Helper()
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
+		if _, err := reconciler.Reconcile(context.TODO(), req); err != nil {
+			t.Fatalf("Reconcile failed: %v", err)
+		}
+	}
+	countJobs := func() int {
+		t.Helper()
+		var jobs batchv1.JobList
+		if err := fakeClient.List(context.TODO(), &jobs, client.InNamespace("default")); err != nil {
+			t.Fatalf("Failed to list jobs: %v", err)
+		}
+		return len(jobs.Items)
+	}
+
+	// Events that occurred before the operator started are ignored
+	reconcile(staleEvent.Name)
+	if got := countJobs(); got != 0 {
+		t.Fatalf("Expected stale event to be ignored, got %d jobs", got)
+	}
+
+	// Resyncs of the same occurrence are processed once
+	reconcile(event.Name)
+	reconcile(event.Name)
+	if got := countJobs(); got != 1 {
+		t.Fatalf("Expected 1 job for the first occurrence, got %d", got)
+	}
+
+	var jobs batchv1.JobList
+	if err := fakeClient.List(context.TODO(), &jobs, client.InNamespace("default")); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if env := jobs.Items[0].Spec.Template.Spec.Containers[0].Env; len(env) != 1 || env[0].Value != "api-1" {
+		t.Errorf("Expected POD to resolve from the regarding object, got %+v", env)
+	}
+
+	// A new occurrence of the event is processed again
+	var current eventsv1.Event
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: event.Name, Namespace: "default"}, &current); err != nil {
+		t.Fatalf("Failed to get Event: %v", err)
+	}
+	current.Series = &eventsv1.EventSeries{Count: 2, LastObservedTime: metav1.NewMicroTime(time.Now())}
+	if err := fakeClient.Update(context.TODO(), &current); err != nil {
+		t.Fatalf("Failed to update Event: %v", err)
+	}
+	reconcile(event.Name)
+	if got := countJobs(); got != 2 {
+		t.Errorf("Expected 2 jobs after the event recurred, got %d", got)
+	}
+}
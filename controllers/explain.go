@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+// RedactedValue replaces values resolved from Secrets in explained jobs
+const RedactedValue = "<redacted>"
+
+// MatcherExplanation describes how a single matcher evaluated against an alert
+type MatcherExplanation struct {
+	Name        string                              `json:"name"`
+	Operator    alertreactionv1alpha1.MatchOperator `json:"operator"`
+	Value       string                              `json:"value"`
+	ActualValue string                              `json:"actualValue,omitempty"`
+	Error       string                              `json:"error,omitempty"`
+	Matched     bool                                `json:"matched"`
+}
+
+// MatcherGroupExplanation describes how a matcher group evaluated against an alert
+type MatcherGroupExplanation struct {
+	Matchers []MatcherExplanation `json:"matchers"`
+	Matched  bool                 `json:"matched"`
+}
+
+// ReactionExplanation describes whether an AlertReaction would react to an alert and which jobs it would create
+type ReactionExplanation struct {
+	Name             string                             `json:"name"`
+	Namespace        string                             `json:"namespace"`
+	Mode             alertreactionv1alpha1.ReactionMode `json:"mode,omitempty"`
+	AlertNameMatched bool                               `json:"alertNameMatched"`
+	ReceiverMatched  bool                               `json:"receiverMatched"`
+	Matchers         []MatcherExplanation               `json:"matchers,omitempty"`
+	MatcherGroups    []MatcherGroupExplanation          `json:"matcherGroups,omitempty"`
+	Matched          bool                               `json:"matched"`
+
+	// MatchedGroup is the index of the first matcher group that matched the alert
+	MatchedGroup *int `json:"matchedGroup,omitempty"`
+
+	// Reason explains why a matching AlertReaction would not create jobs for the alert
+	Reason string `json:"reason,omitempty"`
+
+	// Jobs are the jobs that would be created, with values from Secrets redacted
+	Jobs   []*batchv1.Job `json:"jobs,omitempty"`
+	Errors []string       `json:"errors,omitempty"`
+}
+
+// ExplainAlert evaluates all AlertReactions against an alert like ProcessAlert does, without creating
+// or cancelling anything. It returns how each AlertReaction matched and the jobs it would create.
+func (r *AlertReactionReconciler) ExplainAlert(ctx context.Context, alertName string, alertData map[string]interface{}) ([]ReactionExplanation, error) {
+	var alertReactionList alertreactionv1alpha1.AlertReactionList
+	if err := r.List(ctx, &alertReactionList); err != nil {
+		return nil, fmt.Errorf("failed to list AlertReactions: %w", err)
+	}
+
+	resolved := alertData["status"] == AlertStatusResolved
+
+	explanations := make([]ReactionExplanation, 0, len(alertReactionList.Items))
+	for i := range alertReactionList.Items {
+		alertReaction := &alertReactionList.Items[i]
+
+		explanation := ReactionExplanation{
+			Name:             alertReaction.Name,
+			Namespace:        alertReaction.Namespace,
+			Mode:             alertReaction.Spec.Mode,
+			AlertNameMatched: alertReaction.Spec.MatchesAlertName(alertName),
+			ReceiverMatched:  len(alertReaction.Spec.Receivers) == 0 || containsString(alertReaction.Spec.Receivers, alertReceiver(alertData)),
+		}
+
+		explanation.Matchers, _ = r.explainMatchers(alertReaction.Spec.Matchers, alertData)
+		for _, group := range alertReaction.Spec.MatcherGroups {
+			var groupExplanation MatcherGroupExplanation
+			groupExplanation.Matchers, groupExplanation.Matched = r.explainMatchers(group, alertData)
+			explanation.MatcherGroups = append(explanation.MatcherGroups, groupExplanation)
+		}
+
+		// The match itself is decided like ProcessAlert does; the matcher details are diagnostics
+		matched, group := r.matchAlert(alertReaction, alertName, alertData)
+		explanation.Matched = matched
+		if matched && group >= 0 {
+			explanation.MatchedGroup = &group
+		}
+		if !explanation.Matched {
+			explanations = append(explanations, explanation)
+			continue
+		}
+
+		actions := alertReaction.Spec.Actions
+		switch {
+		case alertReaction.Spec.Mode == alertreactionv1alpha1.ReactionModeGroup:
+			explanation.Reason = "group-mode AlertReactions create jobs once per notification group, see the groups of the explanation"
+			actions = nil
+		case resolved && len(alertReaction.Spec.OnResolved) == 0:
+			if HandlesResolvedAlerts(alertReaction) {
+				explanation.Reason = "resolved alert only cancels the jobs of the firing alert"
+			} else {
+				explanation.Reason = "AlertReaction has no onResolved actions"
+			}
+			actions = nil
+		case resolved:
+			actions = alertReaction.Spec.OnResolved
+		}
+
+		for _, action := range actions {
+			job, err := r.createJobFromAction(ctx, alertReaction, action, alertData)
+			if err != nil {
+				explanation.Errors = append(explanation.Errors, fmt.Sprintf("action %s: %v", action.Name, err))
+				continue
+			}
+			job.APIVersion = batchv1.SchemeGroupVersion.String()
+			job.Kind = "Job"
+			redactSecretEnv(job, action)
+			explanation.Jobs = append(explanation.Jobs, job)
+		}
+
+		explanations = append(explanations, explanation)
+	}
+
+	return explanations, nil
+}
+
+// ExplainGroup evaluates the group-mode AlertReactions against a notification group like ProcessGroup
+// does, without creating anything. It returns the AlertReactions that would run for the group and the
+// jobs they would create.
+func (r *AlertReactionReconciler) ExplainGroup(ctx context.Context, group *AlertGroup) ([]ReactionExplanation, error) {
+	alertReactions, err := r.listGroupAlertReactions(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	explanations := make([]ReactionExplanation, 0, len(alertReactions))
+	for i := range alertReactions {
+		alertReaction := &alertReactions[i]
+
+		explanation := ReactionExplanation{
+			Name:      alertReaction.Name,
+			Namespace: alertReaction.Namespace,
+			Mode:      alertReaction.Spec.Mode,
+		}
+
+		for _, status := range []string{AlertStatusFiring, AlertStatusResolved} {
+			actions := alertReaction.Spec.Actions
+			if status == AlertStatusResolved {
+				actions = alertReaction.Spec.OnResolved
+			}
+
+			alerts := r.matchingGroupAlerts(alertReaction, group, status)
+			if len(alerts) == 0 {
+				continue
+			}
+			explanation.Matched = true
+
+			groupData := group.jobData(status, alerts)
+			for _, action := range actions {
+				job, err := r.buildGroupJob(ctx, alertReaction, action, groupData)
+				if err != nil {
+					explanation.Errors = append(explanation.Errors, fmt.Sprintf("action %s: %v", action.Name, err))
+					continue
+				}
+				job.APIVersion = batchv1.SchemeGroupVersion.String()
+				job.Kind = "Job"
+				redactSecretEnv(job, action)
+				explanation.Jobs = append(explanation.Jobs, job)
+			}
+		}
+
+		switch {
+		case !explanation.Matched:
+			explanation.Reason = "no alert of the group matches the AlertReaction"
+		case len(explanation.Jobs) == 0 && len(explanation.Errors) == 0:
+			explanation.Reason = "AlertReaction has no actions for the status of the matching alerts"
+		}
+
+		explanations = append(explanations, explanation)
+	}
+
+	return explanations, nil
+}
+
+// explainMatchers evaluates matchers against an alert and returns whether all of them matched
+func (r *AlertReactionReconciler) explainMatchers(matchers []alertreactionv1alpha1.AlertMatcher, alertData map[string]interface{}) ([]MatcherExplanation, bool) {
+	explanations := make([]MatcherExplanation, 0, len(matchers))
+	matched := true
+	for _, matcher := range matchers {
+		explanation := MatcherExplanation{
+			Name:     matcher.Name,
+			Operator: matcher.Operator,
+			Value:    matcher.Value,
+			Matched:  r.evaluateMatcher(matcher, alertData),
+		}
+		if actualValue, err := r.getMatcherValue(matcher.Name, alertData); err != nil {
+			explanation.Error = err.Error()
+		} else {
+			explanation.ActualValue = actualValue
+		}
+		matched = matched && explanation.Matched
+		explanations = append(explanations, explanation)
+	}
+	return explanations, matched
+}
+
+// redactSecretEnv hides the environment variable values of a job that were resolved from Secrets
+func redactSecretEnv(job *batchv1.Job, action alertreactionv1alpha1.Action) {
+	secretEnv := make(map[string]bool)
+	for _, envVar := range action.Env {
+		if envVar.Value == "" && envVar.ValueFrom != nil && envVar.ValueFrom.SecretKeyRef != nil {
+			secretEnv[envVar.Name] = true
+		}
+	}
+
+	for i := range job.Spec.Template.Spec.Containers {
+		container := &job.Spec.Template.Spec.Containers[i]
+		for j := range container.Env {
+			if secretEnv[container.Env[j].Name] && container.Env[j].Value != "" {
+				container.Env[j].Value = RedactedValue
+			}
+		}
+	}
+}
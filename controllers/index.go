@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+const (
+	// AlertNameField is the field index of AlertReactions by the alert names they react to
+	AlertNameField = "spec.alertName"
+
+	// AnyAlertName is the AlertNameField index value of AlertReactions that react to alerts with any name
+	AnyAlertName = "*"
+)
+
+// AlertNameIndexer returns the values of the AlertNameField index for an AlertReaction: its alert
+// names, or AnyAlertName if it has none
+func AlertNameIndexer(obj client.Object) []string {
+	alertReaction, ok := obj.(*alertreactionv1alpha1.AlertReaction)
+	if !ok {
+		return nil
+	}
+	if names := alertReaction.Spec.AllAlertNames(); len(names) > 0 {
+		return names
+	}
+	return []string{AnyAlertName}
+}
+
+// setupIndexes registers the field indexes used to look up AlertReactions with the manager's cache
+func setupIndexes(ctx context.Context, mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(ctx, &alertreactionv1alpha1.AlertReaction{}, AlertNameField, AlertNameIndexer)
+}
+
+// listAlertReactions lists the AlertReactions that react to alerts with the given name or any name
+// through the AlertNameField index, so alerts do not scan every AlertReaction in the cluster.
+// Additional options, like client.InNamespace, restrict the listed AlertReactions.
+func (r *AlertReactionReconciler) listAlertReactions(ctx context.Context, alertName string, opts ...client.ListOption) ([]alertreactionv1alpha1.AlertReaction, error) {
+	var alertReactions []alertreactionv1alpha1.AlertReaction
+	for _, value := range []string{alertName, AnyAlertName} {
+		var alertReactionList alertreactionv1alpha1.AlertReactionList
+		if err := r.List(ctx, &alertReactionList, append([]client.ListOption{client.MatchingFields{AlertNameField: value}}, opts...)...); err != nil {
+			return nil, fmt.Errorf("failed to list AlertReactions: %w", err)
+		}
+		alertReactions = append(alertReactions, alertReactionList.Items...)
+		if alertName == AnyAlertName {
+			break
+		}
+	}
+	return alertReactions, nil
+}
+
+// compiledMatcher is a matcher with its regular expression compiled
+type compiledMatcher struct {
+	alertreactionv1alpha1.AlertMatcher
+
+	// regex is the compiled value of regex matchers. It is nil for other operators and invalid expressions.
+	regex *regexp.Regexp
+}
+
+func compileMatcher(matcher alertreactionv1alpha1.AlertMatcher) compiledMatcher {
+	compiled := compiledMatcher{AlertMatcher: matcher}
+	if matcher.Operator == alertreactionv1alpha1.MatchOperatorRegexMatch || matcher.Operator == alertreactionv1alpha1.MatchOperatorRegexNotMatch {
+		// Invalid expressions never match
+		compiled.regex, _ = regexp.Compile(matcher.Value)
+	}
+	return compiled
+}
+
+// matches evaluates the matcher against alert data
+func (m *compiledMatcher) matches(r *AlertReactionReconciler, alertData map[string]interface{}) bool {
+	// Get the value from alert data
+	actualValue, err := r.getMatcherValue(m.Name, alertData)
+	if err != nil {
+		// If we can't get the value, the matcher fails
+		return false
+	}
+
+	// Evaluate based on operator
+	switch m.Operator {
+	case alertreactionv1alpha1.MatchOperatorEqual:
+		return actualValue == m.Value
+	case alertreactionv1alpha1.MatchOperatorNotEqual:
+		return actualValue != m.Value
+	case alertreactionv1alpha1.MatchOperatorRegexMatch:
+		return m.regex != nil && m.regex.MatchString(actualValue)
+	case alertreactionv1alpha1.MatchOperatorRegexNotMatch:
+		return m.regex == nil || !m.regex.MatchString(actualValue)
+	default:
+		// Unknown operator
+		return false
+	}
+}
+
+// compiledMatchers are the compiled matchers and matcher groups of a generation of an AlertReaction
+type compiledMatchers struct {
+	uid        types.UID
+	generation int64
+	matchers   []compiledMatcher
+	groups     [][]compiledMatcher
+}
+
+// match evaluates the matchers against alert data. It returns whether all matchers and at least one
+// matcher group matched, and the index of the first matching group, or -1 without matcher groups.
+func (c *compiledMatchers) match(r *AlertReactionReconciler, alertData map[string]interface{}) (bool, int) {
+	if !allMatch(r, c.matchers, alertData) {
+		return false, -1
+	}
+	if len(c.groups) == 0 {
+		return true, -1
+	}
+	for i, group := range c.groups {
+		if allMatch(r, group, alertData) {
+			return true, i
+		}
+	}
+	return false, -1
+}
+
+func allMatch(r *AlertReactionReconciler, matchers []compiledMatcher, alertData map[string]interface{}) bool {
+	for i := range matchers {
+		if !matchers[i].matches(r, alertData) {
+			return false
+		}
+	}
+	return true
+}
+
+// matcherCache keeps the compiled matchers of each AlertReaction until its next generation
+type matcherCache struct {
+	mu      sync.RWMutex
+	entries map[types.NamespacedName]*compiledMatchers
+}
+
+// get returns the compiled matchers of an AlertReaction. Matchers of AlertReactions that were not
+// read from the API server have no generation and are compiled on every call.
+func (c *matcherCache) get(alertReaction *alertreactionv1alpha1.AlertReaction) *compiledMatchers {
+	if alertReaction.Generation == 0 {
+		return compileSpecMatchers(alertReaction)
+	}
+
+	key := types.NamespacedName{Namespace: alertReaction.Namespace, Name: alertReaction.Name}
+
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok && entry.uid == alertReaction.UID && entry.generation == alertReaction.Generation {
+		return entry
+	}
+
+	entry = compileSpecMatchers(alertReaction)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[types.NamespacedName]*compiledMatchers)
+	}
+	c.entries[key] = entry
+	return entry
+}
+
+// forget drops the compiled matchers of an AlertReaction
+func (c *matcherCache) forget(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+func compileSpecMatchers(alertReaction *alertreactionv1alpha1.AlertReaction) *compiledMatchers {
+	compiled := &compiledMatchers{
+		uid:        alertReaction.UID,
+		generation: alertReaction.Generation,
+		matchers:   compileMatchers(alertReaction.Spec.Matchers),
+	}
+	for _, group := range alertReaction.Spec.MatcherGroups {
+		compiled.groups = append(compiled.groups, compileMatchers(group))
+	}
+	return compiled
+}
+
+func compileMatchers(matchers []alertreactionv1alpha1.AlertMatcher) []compiledMatcher {
+	compiled := make([]compiledMatcher, len(matchers))
+	for i, matcher := range matchers {
+		compiled[i] = compileMatcher(matcher)
+	}
+	return compiled
+}
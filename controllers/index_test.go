@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+func TestMatcherCache_CompilesOncePerGeneration(t *testing.T) {
+	reconciler, _ := setupTestEmpty()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-reaction", Namespace: "default", UID: "uid-1", Generation: 1},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "HighLatency",
+			Matchers: []alertreactionv1alpha1.AlertMatcher{
+				{Name: "service", Operator: alertreactionv1alpha1.MatchOperatorRegexMatch, Value: "^api-.*"},
+			},
+		},
+	}
+	alertData := map[string]interface{}{"labels": map[string]interface{}{"service": "api-gateway"}}
+
+	first := reconciler.matchers.get(alertReaction)
+	if second := reconciler.matchers.get(alertReaction); second != first {
+		t.Error("Expected the compiled matchers to be reused for the same generation")
+	}
+	if !reconciler.alertMatches(alertReaction, "HighLatency", alertData) {
+		t.Error("Expected the alert to match")
+	}
+
+	// A new generation recompiles the matchers
+	alertReaction.Spec.Matchers[0].Value = "^web-.*"
+	alertReaction.Generation = 2
+	if reconciler.alertMatches(alertReaction, "HighLatency", alertData) {
+		t.Error("Expected the matchers of the new generation to be used")
+	}
+
+	// A recreated AlertReaction with the same name recompiles the matchers
+	alertReaction.UID = "uid-2"
+	alertReaction.Generation = 1
+	alertReaction.Spec.Matchers[0].Value = "^api-.*"
+	if !reconciler.alertMatches(alertReaction, "HighLatency", alertData) {
+		t.Error("Expected the matchers of the recreated AlertReaction to be used")
+	}
+
+	reconciler.matchers.forget(types.NamespacedName{Name: "api-reaction", Namespace: "default"})
+	if len(reconciler.matchers.entries) != 0 {
+		t.Errorf("Expected forgotten matchers to be removed, got %d entries", len(reconciler.matchers.entries))
+	}
+}
+
+func TestCompiledMatcher_InvalidRegex(t *testing.T) {
+	reconciler, _ := setupTestEmpty()
+	alertData := map[string]interface{}{"labels": map[string]interface{}{"service": "api"}}
+
+	match := compileMatcher(alertreactionv1alpha1.AlertMatcher{Name: "service", Operator: alertreactionv1alpha1.MatchOperatorRegexMatch, Value: "[invalid"})
+	if match.matches(reconciler, alertData) {
+		t.Error("Expected an invalid regular expression not to match")
+	}
+	notMatch := compileMatcher(alertreactionv1alpha1.AlertMatcher{Name: "service", Operator: alertreactionv1alpha1.MatchOperatorRegexNotMatch, Value: "[invalid"})
+	if !notMatch.matches(reconciler, alertData) {
+		t.Error("Expected a negated invalid regular expression to match")
+	}
+}
+
+// indexedClient serves AlertReaction lists from an informer indexer like the manager's cache does.
+// The fake client evaluates field selectors by scanning every object.
+type indexedClient struct {
+	client.Client
+	indexer cache.Indexer
+}
+
+func newIndexedClient(c client.Client, alertReactions []client.Object) *indexedClient {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		"field:" + AlertNameField: func(obj interface{}) ([]string, error) {
+			return AlertNameIndexer(obj.(client.Object)), nil
+		},
+	})
+	for _, obj := range alertReactions {
+		_ = indexer.Add(obj)
+	}
+	return &indexedClient{Client: c, indexer: indexer}
+}
+
+func (c *indexedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	alertReactionList, ok := list.(*alertreactionv1alpha1.AlertReactionList)
+	listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+	if !ok || listOpts.FieldSelector == nil {
+		return c.Client.List(ctx, list, opts...)
+	}
+	alertName, _ := listOpts.FieldSelector.RequiresExactMatch(AlertNameField)
+	objs, err := c.indexer.ByIndex("field:"+AlertNameField, alertName)
+	if err != nil {
+		return err
+	}
+	alertReactionList.Items = make([]alertreactionv1alpha1.AlertReaction, len(objs))
+	for i, obj := range objs {
+		alertReactionList.Items[i] = *obj.(*alertreactionv1alpha1.AlertReaction).DeepCopy()
+	}
+	return nil
+}
+
+// BenchmarkProcessAlert processes an alert that a few AlertReactions react to among many others. With the
+// alertName index and compiled matchers, the time per alert does not grow with the number of AlertReactions.
+func BenchmarkProcessAlert(b *testing.B) {
+	for _, count := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("reactions=%d", count), func(b *testing.B) {
+			s := runtime.NewScheme()
+			_ = scheme.AddToScheme(s)
+			_ = alertreactionv1alpha1.AddToScheme(s)
+
+			objects := make([]client.Object, count)
+			for i := range objects {
+				objects[i] = &alertreactionv1alpha1.AlertReaction{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:       fmt.Sprintf("reaction-%d", i),
+						Namespace:  "default",
+						UID:        types.UID(fmt.Sprintf("uid-%d", i)),
+						Generation: 1,
+					},
+					Spec: alertreactionv1alpha1.AlertReactionSpec{
+						// 10 AlertReactions per alert name
+						AlertName: fmt.Sprintf("Alert%d", i/10),
+						Matchers: []alertreactionv1alpha1.AlertMatcher{
+							{Name: "instance", Operator: alertreactionv1alpha1.MatchOperatorRegexMatch, Value: fmt.Sprintf("^node-%d-[a-z]+$", i)},
+							{Name: "severity", Operator: alertreactionv1alpha1.MatchOperatorRegexNotMatch, Value: "^(info|none)$"},
+						},
+						Actions: []alertreactionv1alpha1.Action{{Name: "action", Image: "busybox:latest"}},
+					},
+				}
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(s).
+				WithObjects(objects...).
+				WithIndex(&alertreactionv1alpha1.AlertReaction{}, AlertNameField, AlertNameIndexer).
+				Build()
+			reconciler := &AlertReactionReconciler{Client: newIndexedClient(fakeClient, objects), Scheme: s}
+
+			// The matchers do not match, so no jobs are created and only the lookup is measured
+			alertData := map[string]interface{}{
+				"status": AlertStatusFiring,
+				"labels": map[string]interface{}{"alertname": "Alert5", "instance": "node-x", "severity": "critical"},
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := reconciler.ProcessAlert(context.TODO(), "Alert5", alertData); err != nil {
+					b.Fatalf("ProcessAlert failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestAlertReactionReconciler_ProcessAlertNameForms(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	action := []alertreactionv1alpha1.Action{{Name: "collect", Image: "busybox:latest"}}
+	for _, alertReaction := range []*alertreactionv1alpha1.AlertReaction{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "resource-reaction", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				AlertNames: []string{"HighCPUUsage", "HighMemoryUsage"},
+				Actions:    action,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "critical-reaction", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				Matchers: []alertreactionv1alpha1.AlertMatcher{
+					{Name: "severity", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "critical"},
+				},
+				Actions: action,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "disk-reaction", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				Matchers: []alertreactionv1alpha1.AlertMatcher{
+					{Name: "alertname", Operator: alertreactionv1alpha1.MatchOperatorRegexMatch, Value: "^Disk.*"},
+				},
+				Actions: action,
+			},
+		},
+		{
+			// Without alert names or matchers, an AlertReaction matches nothing
+			ObjectMeta: metav1.ObjectMeta{Name: "empty-reaction", Namespace: "default"},
+			Spec:       alertreactionv1alpha1.AlertReactionSpec{Actions: action},
+		},
+	} {
+		if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+			t.Fatalf("Failed to create AlertReaction: %v", err)
+		}
+	}
+
+	tests := []struct {
+		alertName string
+		severity  string
+		expected  []string
+	}{
+		{alertName: "HighMemoryUsage", severity: "warning", expected: []string{"default/resource-reaction"}},
+		{alertName: "HighCPUUsage", severity: "critical", expected: []string{"default/resource-reaction", "default/critical-reaction"}},
+		{alertName: "DiskFull", severity: "critical", expected: []string{"default/critical-reaction", "default/disk-reaction"}},
+		{alertName: "NodeDown", severity: "info"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alertName+"/"+tt.severity, func(t *testing.T) {
+			alertData := map[string]interface{}{
+				"status": AlertStatusFiring,
+				"labels": map[string]interface{}{"alertname": tt.alertName, "severity": tt.severity},
+			}
+			result, err := reconciler.ProcessAlertWithResult(context.TODO(), tt.alertName, alertData)
+			if err != nil {
+				t.Fatalf("ProcessAlertWithResult failed: %v", err)
+			}
+			matched := map[string]bool{}
+			for _, name := range result.MatchedReactions {
+				matched[name] = true
+			}
+			if len(matched) != len(tt.expected) {
+				t.Errorf("Expected %v to match, got %v", tt.expected, result.MatchedReactions)
+			}
+			for _, name := range tt.expected {
+				if !matched[name] {
+					t.Errorf("Expected %s to match, got %v", name, result.MatchedReactions)
+				}
+			}
+
+			// Jobs are labeled with the name of the alert rather than of the AlertReaction
+			var jobs batchv1.JobList
+			if err := fakeClient.List(context.TODO(), &jobs, client.MatchingLabels{"karo/alert-name": tt.alertName}); err != nil {
+				t.Fatalf("Failed to list jobs: %v", err)
+			}
+			if len(jobs.Items) != len(tt.expected) {
+				t.Errorf("Expected %d jobs labeled with the alert name, got %d", len(tt.expected), len(jobs.Items))
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+const (
+	// TriggerAnnotation requests a manual run of the actions of an AlertReaction when set to a new value
+	TriggerAnnotation = alertreactionv1alpha1.TriggerAnnotation
+
+	// TriggerLabelsAnnotation holds a JSON object of labels for the synthetic alert of a manual run
+	TriggerLabelsAnnotation = "karo.io/trigger-labels"
+
+	// TriggeredByAnnotation identifies who requested a manual run. It is also set on the jobs of manual runs.
+	TriggeredByAnnotation = alertreactionv1alpha1.TriggeredByAnnotation
+
+	// TriggerManual is the trigger of jobs created by manual runs, recorded in the karo/trigger label
+	TriggerManual = "manual"
+)
+
+// Sources of the alert of a manual run
+const (
+	manualTriggerSourceLabels    = "labels"
+	manualTriggerSourceLastAlert = "lastAlert"
+)
+
+// handleManualTrigger runs the actions of an AlertReaction when its karo.io/trigger annotation changed since
+// the last manual run. It reports whether the status was updated and has to be persisted.
+func (r *AlertReactionReconciler) handleManualTrigger(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction) bool {
+	logger := log.FromContext(ctx)
+
+	nonce := alertReaction.Annotations[TriggerAnnotation]
+	if nonce == "" {
+		return false
+	}
+	if last := alertReaction.Status.LastManualTrigger; last != nil && last.Nonce == nonce {
+		return false
+	}
+
+	now := metav1.NewTime(time.Now())
+	trigger := &alertreactionv1alpha1.ManualTrigger{
+		Nonce:       nonce,
+		TriggeredBy: manualTriggerRequester(alertReaction),
+		TriggeredAt: now,
+	}
+	alertReaction.Status.LastManualTrigger = trigger
+
+	alertData, source, err := manualTriggerAlert(alertReaction)
+	if err != nil {
+		logger.Error(err, "invalid manual trigger", "alertReaction", alertReaction.Name)
+		trigger.Source = manualTriggerSourceLabels
+		trigger.Errors = []string{err.Error()}
+		return true
+	}
+	trigger.Source = source
+	alertData["trigger"] = TriggerManual
+	alertData["triggeredBy"] = trigger.TriggeredBy
+
+	logger.Info("Manually triggering AlertReaction", "alertReaction", alertReaction.Name, "triggeredBy", trigger.TriggeredBy, "source", source)
+
+	var jobRefs []alertreactionv1alpha1.JobReference
+	for _, action := range alertReaction.Spec.Actions {
+		var err error
+		var jobName, jobNamespace string
+		if alertReaction.Spec.Mode == alertreactionv1alpha1.ReactionModeGroup {
+			group := &AlertGroup{
+				Status:       AlertStatusFiring,
+				CommonLabels: alertLabels(alertData),
+				Alerts:       []map[string]interface{}{alertData},
+			}
+			groupData := group.jobData(AlertStatusFiring, group.Alerts)
+			groupData["trigger"] = TriggerManual
+			groupData["triggeredBy"] = trigger.TriggeredBy
+			job, createErr := r.createGroupJob(ctx, alertReaction, action, groupData)
+			if err = createErr; err == nil {
+				jobName, jobNamespace = job.Name, job.Namespace
+			}
+		} else {
+			job, createErr := r.createJobFromAction(ctx, alertReaction, action, alertData)
+			if err = createErr; err == nil {
+				if err = r.Create(ctx, job); err != nil {
+					err = fmt.Errorf("failed to create job %s: %w", job.Name, err)
+				}
+				jobName, jobNamespace = job.Name, job.Namespace
+			}
+		}
+		if err != nil {
+			logger.Error(err, "failed to create job for manual trigger", "actionName", action.Name, "alertReaction", alertReaction.Name)
+			trigger.Errors = append(trigger.Errors, fmt.Sprintf("action %s: %v", action.Name, err))
+			continue
+		}
+
+		logger.Info("Created job for manual trigger", "jobName", jobName, "actionName", action.Name, "alertReaction", alertReaction.Name)
+
+		jobRefs = append(jobRefs, alertreactionv1alpha1.JobReference{
+			Name:       jobName,
+			Namespace:  jobNamespace,
+			ActionName: action.Name,
+			CreatedAt:  now,
+		})
+	}
+
+	if len(jobRefs) > 0 {
+		alertReaction.Status.LastTriggered = &now
+		alertReaction.Status.TriggerCount++
+		alertReaction.Status.LastJobsCreated = jobRefs
+	}
+
+	return true
+}
+
+// manualTriggerAlert returns the alert a manual run uses: a synthetic alert if labels were supplied with
+// the karo.io/trigger-labels annotation, the last alert that triggered the AlertReaction otherwise, and a
+// synthetic alert with only the alertname label if there is none.
+func manualTriggerAlert(alertReaction *alertreactionv1alpha1.AlertReaction) (map[string]interface{}, string, error) {
+	labelsJSON, hasLabels := alertReaction.Annotations[TriggerLabelsAnnotation]
+
+	if !hasLabels && alertReaction.Status.LastAlert != nil && len(alertReaction.Status.LastAlert.Raw) > 0 {
+		var alertData map[string]interface{}
+		if err := json.Unmarshal(alertReaction.Status.LastAlert.Raw, &alertData); err != nil {
+			return nil, "", fmt.Errorf("failed to decode last alert: %w", err)
+		}
+		return alertData, manualTriggerSourceLastAlert, nil
+	}
+
+	labels := map[string]string{}
+	if hasLabels {
+		if err := json.Unmarshal([]byte(labelsJSON), &labels); err != nil {
+			return nil, "", fmt.Errorf("annotation %s must be a JSON object of string labels: %w", TriggerLabelsAnnotation, err)
+		}
+	}
+	// Keep an alertname from the labels if the AlertReaction reacts to it
+	if labels["alertname"] == "" || !alertReaction.Spec.MatchesAlertName(labels["alertname"]) {
+		labels["alertname"] = primaryAlertName(alertReaction)
+	}
+
+	alertData := map[string]interface{}{
+		"status":   AlertStatusFiring,
+		"startsAt": time.Now().UTC().Format(time.RFC3339),
+	}
+	setAlertMetadata(alertData, labels, nil)
+
+	return alertData, manualTriggerSourceLabels, nil
+}
+
+// manualTriggerRequester identifies who requested a manual run: the karo.io/triggered-by annotation, which
+// the admission webhook sets to the authenticated user, otherwise the field manager that last set the
+// karo.io/trigger annotation (e.g. "kubectl-annotate")
+func manualTriggerRequester(alertReaction *alertreactionv1alpha1.AlertReaction) string {
+	if triggeredBy := alertReaction.Annotations[TriggeredByAnnotation]; triggeredBy != "" {
+		return triggeredBy
+	}
+
+	field := []byte(`"f:` + TriggerAnnotation + `"`)
+	var requester string
+	var requestedAt time.Time
+	for _, entry := range alertReaction.ManagedFields {
+		if entry.FieldsV1 == nil || !bytes.Contains(entry.FieldsV1.Raw, field) {
+			continue
+		}
+		if entry.Time != nil && entry.Time.Time.Before(requestedAt) {
+			continue
+		}
+		requester = entry.Manager
+		if entry.Time != nil {
+			requestedAt = entry.Time.Time
+		}
+	}
+	return requester
+}
+
+// recordLastAlert stores the data of a firing alert that triggered an AlertReaction, for manual runs
+func recordLastAlert(alertReaction *alertreactionv1alpha1.AlertReaction, alertData map[string]interface{}) error {
+	raw, err := json.Marshal(alertData)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	alertReaction.Status.LastAlert = &runtime.RawExtension{Raw: raw}
+	return nil
+}
+
+// alertLabels returns the labels of alert data as strings
+func alertLabels(alertData map[string]interface{}) map[string]string {
+	labels := make(map[string]string)
+	if alertLabels, ok := alertData["labels"].(map[string]interface{}); ok {
+		for k, v := range alertLabels {
+			labels[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+func TestAlertReactionReconciler_ManualTrigger(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "restart-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "PodCrashLooping",
+			Actions: []alertreactionv1alpha1.Action{
+				{
+					Name:  "restart",
+					Image: "busybox:latest",
+					Env: []alertreactionv1alpha1.EnvVar{
+						{Name: "POD", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+							AlertRef: &alertreactionv1alpha1.AlertFieldSelector{FieldPath: "labels.pod"},
+						}},
+					},
+				},
+			},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	key := types.NamespacedName{Name: "restart-reaction", Namespace: "default"}
+	get := func() *alertreactionv1alpha1.AlertReaction {
+		t.Helper()
+		var current alertreactionv1alpha1.AlertReaction
+		if err := fakeClient.Get(context.TODO(), key, &current); err != nil {
+			t.Fatalf("Failed to get AlertReaction: %v", err)
+		}
+		return &current
+	}
+	trigger := func(annotations map[string]string) {
+		t.Helper()
+		current := get()
+		current.Annotations = annotations
+		if err := fakeClient.Update(context.TODO(), current); err != nil {
+			t.Fatalf("Failed to annotate AlertReaction: %v", err)
+		}
+		if _, err := reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("Reconcile failed: %v", err)
+		}
+	}
+	jobPods := func() []string {
+		t.Helper()
+		var jobs batchv1.JobList
+		if err := fakeClient.List(context.TODO(), &jobs, client.InNamespace("default")); err != nil {
+			t.Fatalf("Failed to list jobs: %v", err)
+		}
+		var pods []string
+		for _, job := range jobs.Items {
+			if job.Labels["karo/trigger"] != TriggerManual {
+				t.Errorf("Expected job %s to be labeled as manually triggered, got %v", job.Name, job.Labels)
+			}
+			if job.Annotations[TriggeredByAnnotation] != "oncall@example.com" {
+				t.Errorf("Expected job %s to record who triggered it, got %v", job.Name, job.Annotations)
+			}
+			pods = append(pods, job.Spec.Template.Spec.Containers[0].Env[0].Value)
+		}
+		return pods
+	}
+
+	// Labels from the annotation build a synthetic alert
+	trigger(map[string]string{
+		TriggerAnnotation:       "1",
+		TriggerLabelsAnnotation: `{"pod":"api-1"}`,
+		TriggeredByAnnotation:   "oncall@example.com",
+	})
+	if pods := jobPods(); len(pods) != 1 || pods[0] != "api-1" {
+		t.Fatalf("Expected 1 job for pod api-1, got %v", pods)
+	}
+	status := get().Status
+	if status.LastManualTrigger == nil || status.LastManualTrigger.Nonce != "1" || status.LastManualTrigger.Source != manualTriggerSourceLabels {
+		t.Fatalf("Expected the manual trigger in the status, got %+v", status.LastManualTrigger)
+	}
+	if status.LastManualTrigger.TriggeredBy != "oncall@example.com" || status.TriggerCount != 1 {
+		t.Errorf("Expected the trigger to be recorded, got %+v and trigger count %d", status.LastManualTrigger, status.TriggerCount)
+	}
+
+	// Reconciling again with the same nonce does not run the actions again
+	if _, err := reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if pods := jobPods(); len(pods) != 1 {
+		t.Fatalf("Expected no new job for the same nonce, got %v", pods)
+	}
+
+	// Without labels, the last alert that triggered the AlertReaction is used
+	alertData := map[string]interface{}{"status": AlertStatusFiring}
+	setAlertMetadata(alertData, map[string]string{"alertname": "PodCrashLooping", "pod": "api-2"}, nil)
+	if err := reconciler.ProcessAlert(context.TODO(), "PodCrashLooping", alertData); err != nil {
+		t.Fatalf("ProcessAlert failed: %v", err)
+	}
+	if err := fakeClient.DeleteAllOf(context.TODO(), &batchv1.Job{}, client.InNamespace("default")); err != nil {
+		t.Fatalf("Failed to delete jobs: %v", err)
+	}
+
+	trigger(map[string]string{
+		TriggerAnnotation:     "2",
+		TriggeredByAnnotation: "oncall@example.com",
+	})
+	if pods := jobPods(); len(pods) != 1 || pods[0] != "api-2" {
+		t.Fatalf("Expected 1 job for pod api-2 from the last alert, got %v", pods)
+	}
+	if last := get().Status.LastManualTrigger; last.Nonce != "2" || last.Source != manualTriggerSourceLastAlert {
+		t.Errorf("Expected the last alert to be the source, got %+v", last)
+	}
+}
+
+func TestManualTriggerRequester(t *testing.T) {
+	older := metav1.Unix(100, 0)
+	newer := metav1.Unix(200, 0)
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl-annotate", Time: &newer, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:annotations":{"f:karo.io/trigger":{}}}}`)}},
+				{Manager: "kubectl-client-side-apply", Time: &older, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:annotations":{"f:karo.io/trigger":{}}}}`)}},
+				{Manager: "karo", Time: &newer, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:status":{}}`)}},
+			},
+		},
+	}
+
+	if got := manualTriggerRequester(alertReaction); got != "kubectl-annotate" {
+		t.Errorf("Expected the manager of the trigger annotation, got %q", got)
+	}
+
+	alertReaction.Annotations = map[string]string{TriggeredByAnnotation: "jane"}
+	if got := manualTriggerRequester(alertReaction); got != "jane" {
+		t.Errorf("Expected the triggered-by annotation to take precedence, got %q", got)
+	}
+}
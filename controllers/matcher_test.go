@@ -206,6 +206,67 @@ func TestAlertMatching(t *testing.T) {
 			shouldMatch: false,
 			description: "AlertReaction with multiple matchers should not match when one matcher fails",
 		},
+		{
+			name: "receiver-should-match",
+			alertReaction: &alertreactionv1alpha1.AlertReaction{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver-match", Namespace: "default"},
+				Spec: alertreactionv1alpha1.AlertReactionSpec{
+					AlertName: "TestAlert",
+					Receivers: []string{"team-a", "team-b"},
+					Actions: []alertreactionv1alpha1.Action{
+						{Name: "test-action", Image: "busybox:latest", Command: []string{"echo", "hello"}},
+					},
+				},
+			},
+			alertData: map[string]interface{}{
+				"receiver": "team-b",
+				"labels":   map[string]interface{}{"severity": "critical"},
+			},
+			shouldMatch: true,
+			description: "AlertReaction with receivers should match alerts from one of its receivers",
+		},
+		{
+			name: "receiver-should-not-match",
+			alertReaction: &alertreactionv1alpha1.AlertReaction{
+				ObjectMeta: metav1.ObjectMeta{Name: "receiver-no-match", Namespace: "default"},
+				Spec: alertreactionv1alpha1.AlertReactionSpec{
+					AlertName: "TestAlert",
+					Receivers: []string{"team-a"},
+					Actions: []alertreactionv1alpha1.Action{
+						{Name: "test-action", Image: "busybox:latest", Command: []string{"echo", "hello"}},
+					},
+				},
+			},
+			alertData: map[string]interface{}{
+				"receiver": "team-b",
+				"labels":   map[string]interface{}{"severity": "critical"},
+			},
+			shouldMatch: false,
+			description: "AlertReaction with receivers should not match alerts from other receivers",
+		},
+		{
+			name: "notification-matcher-should-match",
+			alertReaction: &alertreactionv1alpha1.AlertReaction{
+				ObjectMeta: metav1.ObjectMeta{Name: "notification-match", Namespace: "default"},
+				Spec: alertreactionv1alpha1.AlertReactionSpec{
+					AlertName: "TestAlert",
+					Matchers: []alertreactionv1alpha1.AlertMatcher{
+						{Name: "notification.commonLabels.team", Operator: "=", Value: "platform"},
+					},
+					Actions: []alertreactionv1alpha1.Action{
+						{Name: "test-action", Image: "busybox:latest", Command: []string{"echo", "hello"}},
+					},
+				},
+			},
+			alertData: map[string]interface{}{
+				"labels": map[string]interface{}{"severity": "critical"},
+				"notification": map[string]interface{}{
+					"commonLabels": map[string]interface{}{"team": "platform"},
+				},
+			},
+			shouldMatch: true,
+			description: "AlertReaction with a nested notification matcher should match the notification fields",
+		},
 	}
 
 	// Create all AlertReactions
@@ -322,3 +383,73 @@ func TestProcessMultipleAlertReactions(t *testing.T) {
 		t.Errorf("Expected AlertReaction 3 trigger count 1, got %d", updatedReaction3.Status.TriggerCount)
 	}
 }
+
+func TestMatcherGroups(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	// Production alerts that are either critical or owned by the SRE team
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "page-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "ServiceDown",
+			Matchers: []alertreactionv1alpha1.AlertMatcher{
+				{Name: "environment", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "production"},
+			},
+			MatcherGroups: [][]alertreactionv1alpha1.AlertMatcher{
+				{{Name: "severity", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "critical"}},
+				{
+					{Name: "team", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "sre"},
+					{Name: "severity", Operator: alertreactionv1alpha1.MatchOperatorNotEqual, Value: "info"},
+				},
+			},
+			Actions: []alertreactionv1alpha1.Action{{Name: "page", Image: "busybox:latest"}},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]interface{}
+		group  int
+	}{
+		{name: "first group", labels: map[string]interface{}{"environment": "production", "severity": "critical", "team": "sre"}, group: 0},
+		{name: "second group", labels: map[string]interface{}{"environment": "production", "severity": "warning", "team": "sre"}, group: 1},
+		{name: "no group", labels: map[string]interface{}{"environment": "production", "severity": "info", "team": "sre"}, group: -1},
+		{name: "top-level matcher", labels: map[string]interface{}{"environment": "staging", "severity": "critical"}, group: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alertData := map[string]interface{}{"status": AlertStatusFiring, "labels": tt.labels}
+
+			result, err := reconciler.ProcessAlertWithResult(context.TODO(), "ServiceDown", alertData)
+			if err != nil {
+				t.Fatalf("ProcessAlertWithResult failed: %v", err)
+			}
+			group, ok := result.MatchedGroups["default/page-reaction"]
+			if tt.group < 0 {
+				if len(result.MatchedReactions) != 0 || ok {
+					t.Errorf("Expected no match, got %v and groups %v", result.MatchedReactions, result.MatchedGroups)
+				}
+			} else if len(result.MatchedReactions) != 1 || group != tt.group {
+				t.Errorf("Expected a match by group %d, got %v and groups %v", tt.group, result.MatchedReactions, result.MatchedGroups)
+			}
+
+			explanations, err := reconciler.ExplainAlert(context.TODO(), "ServiceDown", alertData)
+			if err != nil {
+				t.Fatalf("ExplainAlert failed: %v", err)
+			}
+			explanation := explanations[0]
+			if len(explanation.MatcherGroups) != 2 {
+				t.Fatalf("Expected both matcher groups to be explained, got %+v", explanation.MatcherGroups)
+			}
+			if explanation.Matched != (tt.group >= 0) {
+				t.Errorf("Expected matched to be %v, got %v", tt.group >= 0, explanation.Matched)
+			}
+			if tt.group >= 0 && (explanation.MatchedGroup == nil || *explanation.MatchedGroup != tt.group) {
+				t.Errorf("Expected group %d to be reported as matched, got %v", tt.group, explanation.MatchedGroup)
+			}
+		})
+	}
+}
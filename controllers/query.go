@@ -0,0 +1,443 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+const (
+	// DefaultQueryInterval is the interval between evaluations of query triggers without an interval
+	DefaultQueryInterval = time.Minute
+
+	// TriggerQuery is the trigger of jobs created by query triggers, recorded in the karo/trigger label
+	TriggerQuery = "query"
+
+	// DefaultQueryWorkers is the number of query triggers evaluated concurrently
+	DefaultQueryWorkers = 4
+
+	// DefaultQueryTimeout bounds a single query to Prometheus
+	DefaultQueryTimeout = 10 * time.Second
+)
+
+// errNoPrometheus is reported by query triggers when no Prometheus URL is configured
+var errNoPrometheus = errors.New("no Prometheus URL configured, set --prometheus-url")
+
+// PrometheusClient evaluates PromQL expressions with the Prometheus HTTP API
+type PrometheusClient struct {
+	// URL is the base URL of the Prometheus HTTP API, e.g. http://prometheus:9090
+	URL string
+
+	// HTTPClient is used for queries. http.DefaultClient is used if unset.
+	HTTPClient *http.Client
+}
+
+// Sample is a series returned by an instant query
+type Sample struct {
+	Labels map[string]string
+	Value  string
+}
+
+// queryResponse is the response of the Prometheus /api/v1/query endpoint
+type queryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// Query evaluates an instant query. Vector results return a sample per series and scalar results
+// a single sample without labels.
+func (p *PrometheusClient) Query(ctx context.Context, expr string) ([]Sample, error) {
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	form := url.Values{"query": {expr}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(p.URL, "/")+"/api/v1/query", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var response queryResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("unexpected response with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("query failed: %s: %s", response.ErrorType, response.Error)
+	}
+
+	switch response.Data.ResultType {
+	case "vector":
+		var series []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		}
+		if err := json.Unmarshal(response.Data.Result, &series); err != nil {
+			return nil, fmt.Errorf("invalid vector result: %w", err)
+		}
+		samples := make([]Sample, len(series))
+		for i, s := range series {
+			samples[i] = Sample{Labels: s.Metric, Value: fmt.Sprintf("%v", s.Value[1])}
+		}
+		return samples, nil
+	case "scalar":
+		var value [2]interface{}
+		if err := json.Unmarshal(response.Data.Result, &value); err != nil {
+			return nil, fmt.Errorf("invalid scalar result: %w", err)
+		}
+		return []Sample{{Labels: map[string]string{}, Value: fmt.Sprintf("%v", value[1])}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type %q, the expression must return an instant vector", response.Data.ResultType)
+	}
+}
+
+// trackedQuery holds the series returned by the last evaluation of a query trigger
+type trackedQuery struct {
+	uid         types.UID
+	expr        string
+	evaluatedAt time.Time
+
+	// series holds the alert data of each returned series, by fingerprint
+	series map[string]map[string]interface{}
+}
+
+// queryTracker keeps the state of the query triggers of all AlertReactions in memory. The firing
+// series are also recorded in the status of the AlertReactions, to restore the state after a restart.
+type queryTracker struct {
+	mu      sync.Mutex
+	queries map[types.NamespacedName]*trackedQuery
+}
+
+// get returns the state of a query trigger, resetting it when the AlertReaction was recreated
+// or its expression changed. New state is restored from the firing series in the status.
+func (t *queryTracker) get(alertReaction *alertreactionv1alpha1.AlertReaction) *trackedQuery {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := types.NamespacedName{Namespace: alertReaction.Namespace, Name: alertReaction.Name}
+	query, ok := t.queries[key]
+	if !ok || query.uid != alertReaction.UID || query.expr != alertReaction.Spec.Query.Expr {
+		if t.queries == nil {
+			t.queries = make(map[types.NamespacedName]*trackedQuery)
+		}
+		query = &trackedQuery{
+			uid:    alertReaction.UID,
+			expr:   alertReaction.Spec.Query.Expr,
+			series: make(map[string]map[string]interface{}),
+		}
+		if status := alertReaction.Status.Query; status != nil && status.Expr == query.expr {
+			for _, series := range status.Series {
+				query.series[series.Fingerprint] = sampleToAlert(series.Labels["alertname"],
+					Sample{Labels: series.Labels, Value: series.Value}, series.StartsAt.Time)
+			}
+		}
+		t.queries[key] = query
+	}
+	return query
+}
+
+// forget drops the state of a query trigger
+func (t *queryTracker) forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.queries, key)
+}
+
+// QueryReconciler evaluates the query triggers of AlertReactions. It runs as a controller of its own,
+// leader-elected like the AlertReaction controller, so slow queries only hold up other query triggers.
+type QueryReconciler struct {
+	*AlertReactionReconciler
+
+	// Workers is the number of query triggers evaluated concurrently. DefaultQueryWorkers is used if unset.
+	Workers int
+
+	// Timeout bounds a single query to Prometheus. DefaultQueryTimeout is used if unset.
+	Timeout time.Duration
+}
+
+// Reconcile evaluates the query trigger of an AlertReaction when it is due and requeues it for the next evaluation
+func (r *QueryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var alertReaction alertreactionv1alpha1.AlertReaction
+	if err := r.Get(ctx, req.NamespacedName, &alertReaction); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.queries.forget(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	patch := client.MergeFrom(alertReaction.DeepCopy())
+
+	requeueAfter, updated := r.evaluateQuery(ctx, &alertReaction)
+	if updated {
+		// Patch without optimistic locking, so a conflict cannot run a query trigger twice
+		if err := r.Status().Patch(ctx, &alertReaction, patch); err != nil {
+			logger.Error(err, "unable to update AlertReaction query status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// SetupWithManager sets up the query trigger controller with the Manager
+func (r *QueryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	workers := r.Workers
+	if workers <= 0 {
+		workers = DefaultQueryWorkers
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("alertreaction-query").
+		For(&alertreactionv1alpha1.AlertReaction{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: workers}).
+		Complete(r)
+}
+
+// evaluateQuery evaluates the query trigger of an AlertReaction when it is due. Series that were not
+// returned by the previous evaluation fire, and series that are no longer returned resolve.
+// It returns when the next evaluation is due and whether the status was updated.
+func (r *QueryReconciler) evaluateQuery(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction) (time.Duration, bool) {
+	logger := log.FromContext(ctx)
+
+	if alertReaction.Spec.Query == nil {
+		r.queries.forget(types.NamespacedName{Namespace: alertReaction.Namespace, Name: alertReaction.Name})
+		if alertReaction.Status.Query != nil {
+			alertReaction.Status.Query = nil
+			return 0, true
+		}
+		return 0, false
+	}
+
+	if alertReaction.Status.Query == nil {
+		alertReaction.Status.Query = &alertreactionv1alpha1.QueryStatus{}
+	}
+	status := alertReaction.Status.Query
+
+	interval := DefaultQueryInterval
+	if alertReaction.Spec.Query.Interval != "" {
+		parsed, err := time.ParseDuration(alertReaction.Spec.Query.Interval)
+		if err != nil || parsed <= 0 {
+			status.LastError = fmt.Sprintf("invalid interval %q", alertReaction.Spec.Query.Interval)
+			return 0, true
+		}
+		interval = parsed
+	}
+
+	query := r.queries.get(alertReaction)
+	now := time.Now()
+	lastEvaluated := query.evaluatedAt
+	if lastEvaluated.IsZero() && status.LastEvaluationTime != nil {
+		lastEvaluated = status.LastEvaluationTime.Time
+	}
+	if next := lastEvaluated.Add(interval); now.Before(next) {
+		return next.Sub(now), false
+	}
+
+	query.evaluatedAt = now
+	status.LastEvaluationTime = &metav1.Time{Time: now}
+
+	var samples []Sample
+	err := errNoPrometheus
+	if r.Prometheus != nil {
+		timeout := r.Timeout
+		if timeout <= 0 {
+			timeout = DefaultQueryTimeout
+		}
+		queryCtx, cancel := context.WithTimeout(ctx, timeout)
+		samples, err = r.Prometheus.Query(queryCtx, alertReaction.Spec.Query.Expr)
+		cancel()
+	}
+	if err != nil {
+		logger.Error(err, "failed to evaluate query", "alertReaction", alertReaction.Name)
+		status.LastError = err.Error()
+		return interval, true
+	}
+	status.LastError = ""
+	status.ActiveSeries = int32(len(samples))
+
+	current := make(map[string]map[string]interface{}, len(samples))
+	var firing []map[string]interface{}
+	for _, sample := range samples {
+		alertData := sampleToAlert(queryAlertName(alertReaction, sample), sample, now)
+		fingerprint := alertFingerprint(alertData)
+		if previous, ok := query.series[fingerprint]; ok {
+			current[fingerprint] = previous
+			continue
+		}
+		current[fingerprint] = alertData
+		firing = append(firing, alertData)
+	}
+
+	var resolved []map[string]interface{}
+	for fingerprint, previous := range query.series {
+		if _, ok := current[fingerprint]; ok {
+			continue
+		}
+		alertData := make(map[string]interface{}, len(previous))
+		for k, v := range previous {
+			alertData[k] = v
+		}
+		alertData["status"] = AlertStatusResolved
+		alertData["endsAt"] = now.UTC().Format(time.RFC3339)
+		resolved = append(resolved, alertData)
+	}
+	query.series = current
+	status.Expr = alertReaction.Spec.Query.Expr
+	status.Series = querySeriesStatus(current)
+
+	if len(firing) > 0 || len(resolved) > 0 {
+		logger.Info("Query trigger series changed", "alertReaction", alertReaction.Name, "firing", len(firing), "resolved", len(resolved))
+		r.runQueryAlerts(ctx, alertReaction, firing, resolved)
+	}
+
+	return interval, true
+}
+
+// querySeriesStatus returns the firing series of a query trigger to record in the status, by fingerprint
+func querySeriesStatus(series map[string]map[string]interface{}) []alertreactionv1alpha1.QuerySeries {
+	fingerprints := make([]string, 0, len(series))
+	for fingerprint := range series {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+
+	var statuses []alertreactionv1alpha1.QuerySeries
+	for _, fingerprint := range fingerprints {
+		alertData := series[fingerprint]
+		status := alertreactionv1alpha1.QuerySeries{Fingerprint: fingerprint, Labels: map[string]string{}}
+		if labels, ok := alertData["labels"].(map[string]interface{}); ok {
+			for k, v := range labels {
+				status.Labels[k] = fmt.Sprintf("%v", v)
+			}
+		}
+		status.Value, _ = alertData["value"].(string)
+		if startsAt, ok := alertData["startsAt"].(string); ok {
+			if parsed, err := time.Parse(time.RFC3339, startsAt); err == nil {
+				status.StartsAt = metav1.NewTime(parsed)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// queryAlertName is the name of the alert of a series returned by a query trigger: the first alert name
+// of the AlertReaction, or the alertname label of the series if the AlertReaction reacts to any name
+func queryAlertName(alertReaction *alertreactionv1alpha1.AlertReaction, sample Sample) string {
+	if len(alertReaction.Spec.AllAlertNames()) == 0 && sample.Labels["alertname"] != "" {
+		return sample.Labels["alertname"]
+	}
+	return primaryAlertName(alertReaction)
+}
+
+// sampleToAlert converts a series returned by a query trigger into alert data
+func sampleToAlert(alertName string, sample Sample, now time.Time) map[string]interface{} {
+	labels := make(map[string]string, len(sample.Labels)+1)
+	for k, v := range sample.Labels {
+		labels[k] = v
+	}
+	labels["alertname"] = alertName
+
+	alertData := map[string]interface{}{
+		"status":      AlertStatusFiring,
+		"startsAt":    now.UTC().Format(time.RFC3339),
+		"fingerprint": LabelsFingerprint(labels),
+		"value":       sample.Value,
+		"trigger":     TriggerQuery,
+	}
+	setAlertMetadata(alertData, labels, nil)
+	return alertData
+}
+
+// runQueryAlerts runs the actions of an AlertReaction for the series of its query trigger that started
+// firing and its onResolved actions for the series that resolved
+func (r *AlertReactionReconciler) runQueryAlerts(ctx context.Context, alertReaction *alertreactionv1alpha1.AlertReaction, firing, resolved []map[string]interface{}) {
+	now := metav1.NewTime(time.Now())
+
+	if alertReaction.Spec.Mode == alertreactionv1alpha1.ReactionModeGroup {
+		group := &AlertGroup{
+			GroupKey:    "query/" + alertReaction.Namespace + "/" + alertReaction.Name,
+			Status:      AlertStatusResolved,
+			GroupLabels: map[string]string{"alertname": primaryAlertName(alertReaction)},
+			Alerts:      append(append([]map[string]interface{}{}, firing...), resolved...),
+		}
+		if len(firing) > 0 {
+			group.Status = AlertStatusFiring
+		}
+		if jobRefs := r.runGroup(ctx, alertReaction, group, now); len(jobRefs) > 0 {
+			alertReaction.Status.LastTriggered = &now
+			alertReaction.Status.TriggerCount++
+			alertReaction.Status.LastJobsCreated = jobRefs
+		}
+		return
+	}
+
+	result := &AlertResult{}
+	var jobRefs []alertreactionv1alpha1.JobReference
+	triggered := false
+	for _, alertData := range firing {
+		if !r.alertMatches(alertReaction, groupAlertName(alertData), alertData) {
+			continue
+		}
+		if err := recordLastAlert(alertReaction, alertData); err != nil {
+			log.FromContext(ctx).Error(err, "failed to record last alert", "alertReaction", alertReaction.Name)
+		}
+		jobRefs = append(jobRefs, r.runActions(ctx, alertReaction, alertReaction.Spec.Actions, alertData, now, result)...)
+		alertReaction.Status.TriggerCount++
+		triggered = true
+	}
+	for _, alertData := range resolved {
+		if !HandlesResolvedAlerts(alertReaction) || !r.alertMatches(alertReaction, groupAlertName(alertData), alertData) {
+			continue
+		}
+		r.cancelJobsForResolvedAlert(ctx, alertReaction, alertData)
+		if len(alertReaction.Spec.OnResolved) == 0 {
+			continue
+		}
+		jobRefs = append(jobRefs, r.runActions(ctx, alertReaction, alertReaction.Spec.OnResolved, alertData, now, result)...)
+		alertReaction.Status.TriggerCount++
+		triggered = true
+	}
+
+	if triggered {
+		alertReaction.Status.LastTriggered = &now
+		alertReaction.Status.LastJobsCreated = jobRefs
+	}
+}
@@ -0,0 +1,325 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+// fakePrometheus answers instant queries with a configurable vector
+type fakePrometheus struct {
+	mu     sync.Mutex
+	series []map[string]string
+	query  string
+}
+
+func (f *fakePrometheus) set(series ...map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.series = series
+}
+
+func (f *fakePrometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path != "/api/v1/query" {
+		http.NotFound(w, r)
+		return
+	}
+	f.query = r.FormValue("query")
+
+	result := make([]interface{}, len(f.series))
+	for i, metric := range f.series {
+		result[i] = map[string]interface{}{"metric": metric, "value": []interface{}{1700000000.0, "3"}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": "vector", "result": result},
+	})
+}
+
+func TestAlertReactionReconciler_QueryTrigger(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	prometheus := &fakePrometheus{}
+	server := httptest.NewServer(prometheus)
+	defer server.Close()
+	reconciler.Prometheus = &PrometheusClient{URL: server.URL}
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "unavailable-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "DeploymentUnavailable",
+			Query: &alertreactionv1alpha1.QueryTrigger{
+				Expr:     "kube_deployment_status_replicas_unavailable > 0",
+				Interval: "1m",
+			},
+			Matchers: []alertreactionv1alpha1.AlertMatcher{
+				{Name: "namespace", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "prod"},
+			},
+			Actions: []alertreactionv1alpha1.Action{
+				{
+					Name:  "restart",
+					Image: "busybox:latest",
+					Env: []alertreactionv1alpha1.EnvVar{
+						{Name: "DEPLOYMENT", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+							AlertRef: &alertreactionv1alpha1.AlertFieldSelector{FieldPath: "labels.deployment"},
+						}},
+						{Name: "VALUE", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+							AlertRef: &alertreactionv1alpha1.AlertFieldSelector{FieldPath: "value"},
+						}},
+					},
+				},
+			},
+			OnResolved: []alertreactionv1alpha1.Action{
+				{Name: "notify", Image: "busybox:latest"},
+			},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	key := types.NamespacedName{Name: "unavailable-reaction", Namespace: "default"}
+	queryReconciler := &QueryReconciler{AlertReactionReconciler: reconciler}
+	reconcile := func() ctrl.Result {
+		t.Helper()
+		result, err := queryReconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("Reconcile failed: %v", err)
+		}
+		return result
+	}
+	makeDue := func() {
+		reconciler.queries.queries[key].evaluatedAt = time.Now().Add(-2 * time.Minute)
+	}
+	jobsFor := func(actionName string) []batchv1.Job {
+		t.Helper()
+		var jobs batchv1.JobList
+		if err := fakeClient.List(context.TODO(), &jobs, client.InNamespace("default"), client.MatchingLabels{"karo/action-name": actionName}); err != nil {
+			t.Fatalf("Failed to list jobs: %v", err)
+		}
+		return jobs.Items
+	}
+
+	prometheus.set(
+		map[string]string{"namespace": "prod", "deployment": "api"},
+		map[string]string{"namespace": "staging", "deployment": "api"},
+	)
+	result := reconcile()
+	if prometheus.query != alertReaction.Spec.Query.Expr {
+		t.Errorf("Expected the expression to be queried, got %q", prometheus.query)
+	}
+	if result.RequeueAfter != time.Minute {
+		t.Errorf("Expected the next evaluation in 1m, got %v", result.RequeueAfter)
+	}
+
+	// Series labels feed matchers and env vars
+	jobs := jobsFor("restart")
+	if len(jobs) != 1 {
+		t.Fatalf("Expected 1 job for the matching series, got %d", len(jobs))
+	}
+	if env := jobs[0].Spec.Template.Spec.Containers[0].Env; len(env) != 2 || env[0].Value != "api" || env[1].Value != "3" {
+		t.Errorf("Expected env from the series labels and value, got %+v", env)
+	}
+	if jobs[0].Labels["karo/trigger"] != TriggerQuery {
+		t.Errorf("Expected job to be labeled as created by the query trigger, got %v", jobs[0].Labels)
+	}
+
+	var updated alertreactionv1alpha1.AlertReaction
+	if err := fakeClient.Get(context.TODO(), key, &updated); err != nil {
+		t.Fatalf("Failed to get AlertReaction: %v", err)
+	}
+	if updated.Status.Query == nil || updated.Status.Query.ActiveSeries != 2 || updated.Status.Query.LastEvaluationTime == nil {
+		t.Errorf("Expected the evaluation in the status, got %+v", updated.Status.Query)
+	}
+
+	// Series keep firing without running the actions again, also once the next evaluation is due
+	if result := reconcile(); result.RequeueAfter <= 0 || result.RequeueAfter > time.Minute {
+		t.Errorf("Expected the remaining interval to be requeued, got %v", result.RequeueAfter)
+	}
+	makeDue()
+	reconcile()
+	if got := len(jobsFor("restart")); got != 1 {
+		t.Fatalf("Expected no new job while the series keeps firing, got %d", got)
+	}
+
+	// Series that are no longer returned resolve
+	prometheus.set(map[string]string{"namespace": "staging", "deployment": "api"})
+	makeDue()
+	reconcile()
+	if got := len(jobsFor("notify")); got != 1 {
+		t.Errorf("Expected 1 onResolved job, got %d", got)
+	}
+}
+
+func TestPrometheusClient_QueryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	}))
+	defer server.Close()
+
+	prometheus := &PrometheusClient{URL: server.URL}
+	if _, err := prometheus.Query(context.TODO(), "up{"); err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Errorf("Expected the Prometheus error to be reported, got %v", err)
+	}
+}
+
+func TestQueryReconciler_Timeout(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	reconciler.Prometheus = &PrometheusClient{URL: server.URL}
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "slow-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "DeploymentUnavailable",
+			Query:     &alertreactionv1alpha1.QueryTrigger{Expr: "up == 0", Interval: "1m"},
+			Actions:   []alertreactionv1alpha1.Action{{Name: "restart", Image: "busybox:latest"}},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	key := types.NamespacedName{Name: "slow-reaction", Namespace: "default"}
+	queryReconciler := &QueryReconciler{AlertReactionReconciler: reconciler, Timeout: 50 * time.Millisecond}
+	start := time.Now()
+	result, err := queryReconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the query to time out, took %v", elapsed)
+	}
+	if result.RequeueAfter != time.Minute {
+		t.Errorf("Expected the next evaluation in 1m, got %v", result.RequeueAfter)
+	}
+
+	var updated alertreactionv1alpha1.AlertReaction
+	if err := fakeClient.Get(context.TODO(), key, &updated); err != nil {
+		t.Fatalf("Failed to get AlertReaction: %v", err)
+	}
+	if updated.Status.Query == nil || updated.Status.Query.LastError == "" {
+		t.Errorf("Expected the timeout to be reported in the status, got %+v", updated.Status.Query)
+	}
+}
+
+func TestAlertReactionReconciler_QueryTriggerSurvivesRestart(t *testing.T) {
+	reconciler, fakeClient := setupTestEmpty()
+
+	prometheus := &fakePrometheus{}
+	server := httptest.NewServer(prometheus)
+	defer server.Close()
+	reconciler.Prometheus = &PrometheusClient{URL: server.URL}
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "unavailable-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "DeploymentUnavailable",
+			Query: &alertreactionv1alpha1.QueryTrigger{
+				Expr:     "kube_deployment_status_replicas_unavailable > 0",
+				Interval: "1m",
+			},
+			Actions:    []alertreactionv1alpha1.Action{{Name: "restart", Image: "busybox:latest"}},
+			OnResolved: []alertreactionv1alpha1.Action{{Name: "notify", Image: "busybox:latest"}},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	key := types.NamespacedName{Name: "unavailable-reaction", Namespace: "default"}
+	queryReconciler := &QueryReconciler{AlertReactionReconciler: reconciler}
+	reconcile := func() {
+		t.Helper()
+		if _, err := queryReconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("Reconcile failed: %v", err)
+		}
+	}
+	// restart replaces the reconciler, losing its in-memory state, and makes the next evaluation due
+	restart := func() {
+		t.Helper()
+		queryReconciler = &QueryReconciler{AlertReactionReconciler: &AlertReactionReconciler{
+			Client:     fakeClient,
+			Scheme:     reconciler.Scheme,
+			Prometheus: reconciler.Prometheus,
+		}}
+		var updated alertreactionv1alpha1.AlertReaction
+		if err := fakeClient.Get(context.TODO(), key, &updated); err != nil {
+			t.Fatalf("Failed to get AlertReaction: %v", err)
+		}
+		updated.Status.Query.LastEvaluationTime = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+		if err := fakeClient.Status().Update(context.TODO(), &updated); err != nil {
+			t.Fatalf("Failed to update AlertReaction status: %v", err)
+		}
+	}
+	jobsFor := func(actionName string) int {
+		t.Helper()
+		var jobs batchv1.JobList
+		if err := fakeClient.List(context.TODO(), &jobs, client.InNamespace("default"), client.MatchingLabels{"karo/action-name": actionName}); err != nil {
+			t.Fatalf("Failed to list jobs: %v", err)
+		}
+		return len(jobs.Items)
+	}
+
+	prometheus.set(map[string]string{"namespace": "prod", "deployment": "api"})
+	reconcile()
+	if got := jobsFor("restart"); got != 1 {
+		t.Fatalf("Expected 1 job for the firing series, got %d", got)
+	}
+
+	var updated alertreactionv1alpha1.AlertReaction
+	if err := fakeClient.Get(context.TODO(), key, &updated); err != nil {
+		t.Fatalf("Failed to get AlertReaction: %v", err)
+	}
+	if series := updated.Status.Query.Series; len(series) != 1 || series[0].Labels["deployment"] != "api" || series[0].Labels["alertname"] != "DeploymentUnavailable" {
+		t.Fatalf("Expected the firing series in the status, got %+v", series)
+	}
+
+	// A series that keeps firing does not fire again after a restart
+	restart()
+	reconcile()
+	if got := jobsFor("restart"); got != 1 {
+		t.Errorf("Expected no new job after a restart, got %d", got)
+	}
+
+	// A series that stopped firing while the operator was down resolves
+	prometheus.set()
+	restart()
+	reconcile()
+	if got := jobsFor("notify"); got != 1 {
+		t.Errorf("Expected 1 onResolved job, got %d", got)
+	}
+	if err := fakeClient.Get(context.TODO(), key, &updated); err != nil {
+		t.Fatalf("Failed to get AlertReaction: %v", err)
+	}
+	if len(updated.Status.Query.Series) != 0 {
+		t.Errorf("Expected no firing series in the status, got %+v", updated.Status.Query.Series)
+	}
+}
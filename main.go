@@ -4,17 +4,22 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
 	"github.com/dudizimber/karo/controllers"
@@ -36,6 +41,30 @@ func main() {
 	var enableLeaderElection bool
 	var probeAddr string
 	var webhookPort string
+	var webhookAuthSecret string
+	var webhookSignatureSecret string
+	var webhookSignatureHeader string
+	var webhookSignatureTimestampHeader string
+	var webhookSignatureTolerance time.Duration
+	var webhookTLSCertFile string
+	var webhookTLSKeyFile string
+	var webhookTLSClientCAFile string
+	var webhookQueueSize int
+	var webhookQueueWorkers int
+	var webhookShutdownGracePeriod time.Duration
+	var webhookSpoolDir string
+	var webhookHistorySize int
+	var alertmanagerPollURLs string
+	var alertmanagerPollInterval time.Duration
+	var activeAlertStateConfigMap string
+	var prometheusURL string
+	var queryWorkers int
+	var queryTimeout time.Duration
+	var watchEvents bool
+	var watchedEventTypes string
+	var enableAdmissionWebhook bool
+	var admissionWebhookPort int
+	var admissionWebhookCertDir string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -43,6 +72,63 @@ func main() {
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&webhookPort, "webhook-port", "9090", "The port for the webhook server.")
+	flag.StringVar(&webhookAuthSecret, "webhook-auth-secret", "",
+		"Secret (namespace/name) holding the bearer token and/or basic auth credentials "+
+			"required on webhook requests. Authentication is disabled when empty.")
+	flag.StringVar(&webhookSignatureSecret, "webhook-signature-secret", "",
+		"Secret (namespace/name) holding the HMAC-SHA256 signing keys used to verify webhook payloads. "+
+			"Signature verification is disabled when empty.")
+	flag.StringVar(&webhookSignatureHeader, "webhook-signature-header", webhook.DefaultSignatureHeader,
+		"The request header carrying the payload signature.")
+	flag.StringVar(&webhookSignatureTimestampHeader, "webhook-signature-timestamp-header", webhook.DefaultSignatureTimestampHeader,
+		"The request header carrying the Unix timestamp included in the payload signature.")
+	flag.DurationVar(&webhookSignatureTolerance, "webhook-signature-tolerance", webhook.DefaultSignatureTolerance,
+		"The maximum age of a signed webhook request before it is rejected as a replay.")
+	flag.StringVar(&webhookTLSCertFile, "webhook-tls-cert-file", "",
+		"Path to the PEM encoded certificate used to serve the webhook over HTTPS. Plain HTTP is used when empty.")
+	flag.StringVar(&webhookTLSKeyFile, "webhook-tls-key-file", "",
+		"Path to the PEM encoded private key of the webhook serving certificate.")
+	flag.StringVar(&webhookTLSClientCAFile, "webhook-tls-client-ca-file", "",
+		"Path to a PEM encoded CA bundle. When set, webhook clients must present a certificate signed by it.")
+	flag.IntVar(&webhookQueueSize, "webhook-queue-size", webhook.DefaultQueueSize,
+		"The maximum number of webhook payloads queued for processing. Set to 0 to process payloads synchronously.")
+	flag.IntVar(&webhookQueueWorkers, "webhook-queue-workers", webhook.DefaultQueueWorkers,
+		"The number of workers processing queued webhook payloads.")
+	flag.DurationVar(&webhookShutdownGracePeriod, "webhook-shutdown-grace-period", webhook.DefaultShutdownGracePeriod,
+		"How long queued webhook payloads may keep draining after shutdown starts.")
+	flag.StringVar(&webhookSpoolDir, "webhook-spool-dir", "",
+		"Directory where accepted webhook payloads are persisted until processed, and replayed from on startup. "+
+			"Spooling is disabled when empty.")
+	flag.IntVar(&webhookHistorySize, "webhook-history-size", webhook.DefaultHistorySize,
+		"The number of recently received alerts kept for inspection at /alerts and replay. Set to 0 to disable the history.")
+
+	flag.StringVar(&alertmanagerPollURLs, "alertmanager-poll-urls", "",
+		"Comma-separated base URLs of Alertmanagers to poll for alerts, for when Alertmanager cannot reach the webhook. "+
+			"Polling is disabled when empty.")
+	flag.DurationVar(&alertmanagerPollInterval, "alertmanager-poll-interval", webhook.DefaultPollInterval,
+		"How often the Alertmanagers are polled for alerts.")
+	flag.StringVar(&activeAlertStateConfigMap, "active-alert-state-configmap", "default/karo-active-alerts",
+		"ConfigMap (namespace/name) persisting the alerts firing via the Alertmanager API (/api/v2/alerts), "+
+			"so restarts and other replicas do not trigger them again. The state is kept in memory only when empty.")
+
+	flag.StringVar(&prometheusURL, "prometheus-url", "",
+		"Base URL of the Prometheus HTTP API that the query triggers of AlertReactions are evaluated against.")
+	flag.IntVar(&queryWorkers, "query-workers", controllers.DefaultQueryWorkers,
+		"The number of query triggers evaluated concurrently.")
+	flag.DurationVar(&queryTimeout, "query-timeout", controllers.DefaultQueryTimeout,
+		"How long a single query trigger evaluation may take.")
+
+	flag.BoolVar(&watchEvents, "watch-events", false,
+		"Process Kubernetes Events as alerts, with the event reason as alertname.")
+	flag.StringVar(&watchedEventTypes, "watched-event-types", "Warning",
+		"Comma-separated types of the Kubernetes Events processed as alerts. All types are processed when empty.")
+
+	flag.BoolVar(&enableAdmissionWebhook, "enable-admission-webhook", false,
+		"Serve the defaulting and validating admission webhooks for AlertReactions.")
+	flag.IntVar(&admissionWebhookPort, "admission-webhook-port", 9443, "The port the admission webhooks are served on.")
+	flag.StringVar(&admissionWebhookCertDir, "admission-webhook-cert-dir", "",
+		"Directory holding the tls.crt and tls.key used to serve the admission webhooks. "+
+			"Defaults to /tmp/k8s-webhook-server/serving-certs when empty.")
 
 	opts := zap.Options{
 		Development: true,
@@ -59,20 +145,75 @@ func main() {
 		LeaderElection:          enableLeaderElection,
 		LeaderElectionID:        "f1c5ece8.karo.io",
 		LeaderElectionNamespace: "default",
+		WebhookServer: ctrlwebhook.NewServer(ctrlwebhook.Options{
+			Port:    admissionWebhookPort,
+			CertDir: admissionWebhookCertDir,
+		}),
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controllers.AlertReactionReconciler{
+	var prometheus *controllers.PrometheusClient
+	if prometheusURL != "" {
+		prometheus = &controllers.PrometheusClient{
+			URL:        prometheusURL,
+			HTTPClient: &http.Client{Timeout: queryTimeout},
+		}
+		setupLog.Info("Query triggers are evaluated against Prometheus", "url", prometheusURL)
+	}
+
+	// The reconciler also processes alerts from all sources, so they share its caches
+	alertReactionController := &controllers.AlertReactionReconciler{
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Prometheus: prometheus,
+	}
+	if err = alertReactionController.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AlertReaction")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.QueryReconciler{
+		AlertReactionReconciler: alertReactionController,
+		Workers:                 queryWorkers,
+		Timeout:                 queryTimeout,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AlertReactionQuery")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.AlertReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		Alerts: alertReactionController,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "AlertReaction")
+		setupLog.Error(err, "unable to create controller", "controller", "Alert")
 		os.Exit(1)
 	}
 
+	if watchEvents {
+		eventTypes := splitList(watchedEventTypes)
+		if err = (&controllers.EventReconciler{
+			Client: mgr.GetClient(),
+			Alerts: alertReactionController,
+			Types:  eventTypes,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Event")
+			os.Exit(1)
+		}
+		setupLog.Info("Kubernetes Events are processed as alerts", "types", eventTypes)
+	}
+
+	if enableAdmissionWebhook {
+		if err = (&alertreactionv1alpha1.AlertReaction{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "AlertReaction")
+			os.Exit(1)
+		}
+		setupLog.Info("Admission webhooks are served", "port", admissionWebhookPort)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -83,11 +224,75 @@ func main() {
 	}
 
 	// Create webhook server
-	alertReactionController := &controllers.AlertReactionReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+	var webhookOpts []webhook.Option
+	if webhookAuthSecret != "" {
+		webhookOpts = append(webhookOpts, webhook.WithAuth(webhook.AuthConfig{
+			SecretRef: parseNamespacedName(webhookAuthSecret),
+		}))
+		setupLog.Info("Webhook authentication enabled", "secret", webhookAuthSecret)
+	}
+	if webhookSignatureSecret != "" {
+		webhookOpts = append(webhookOpts, webhook.WithSignatureVerification(webhook.SignatureConfig{
+			SecretRef:       parseNamespacedName(webhookSignatureSecret),
+			Header:          webhookSignatureHeader,
+			TimestampHeader: webhookSignatureTimestampHeader,
+			Tolerance:       webhookSignatureTolerance,
+		}))
+		setupLog.Info("Webhook payload signature verification enabled", "secret", webhookSignatureSecret)
+	}
+	if webhookTLSCertFile != "" || webhookTLSKeyFile != "" {
+		if webhookTLSCertFile == "" || webhookTLSKeyFile == "" {
+			setupLog.Error(nil, "both --webhook-tls-cert-file and --webhook-tls-key-file must be set")
+			os.Exit(1)
+		}
+		webhookOpts = append(webhookOpts, webhook.WithTLS(webhook.TLSConfig{
+			CertFile:     webhookTLSCertFile,
+			KeyFile:      webhookTLSKeyFile,
+			ClientCAFile: webhookTLSClientCAFile,
+		}))
+		setupLog.Info("Webhook TLS enabled", "mutualTLS", webhookTLSClientCAFile != "")
+	} else if webhookTLSClientCAFile != "" {
+		setupLog.Error(nil, "--webhook-tls-client-ca-file requires --webhook-tls-cert-file and --webhook-tls-key-file")
+		os.Exit(1)
+	}
+	if webhookQueueSize > 0 {
+		webhookOpts = append(webhookOpts, webhook.WithQueue(webhook.QueueConfig{
+			Size:                webhookQueueSize,
+			Workers:             webhookQueueWorkers,
+			ShutdownGracePeriod: webhookShutdownGracePeriod,
+		}))
+	}
+	if webhookSpoolDir != "" {
+		webhookOpts = append(webhookOpts, webhook.WithSpool(webhook.SpoolConfig{
+			Dir:         webhookSpoolDir,
+			WaitForSync: mgr.GetCache().WaitForCacheSync,
+		}))
+		setupLog.Info("Webhook payload spooling enabled", "dir", webhookSpoolDir)
+	}
+	if webhookHistorySize > 0 {
+		webhookOpts = append(webhookOpts, webhook.WithHistory(webhookHistorySize))
+	}
+	if activeAlertStateConfigMap != "" {
+		webhookOpts = append(webhookOpts, webhook.WithActiveAlertState(webhook.ActiveAlertStateConfig{
+			ConfigMapRef: parseNamespacedName(activeAlertStateConfigMap),
+			Reader:       mgr.GetAPIReader(),
+		}))
+	}
+	if urls := splitList(alertmanagerPollURLs); len(urls) > 0 {
+		webhookOpts = append(webhookOpts, webhook.WithPoller(webhook.PollerConfig{
+			URLs:        urls,
+			Interval:    alertmanagerPollInterval,
+			WaitForSync: mgr.GetCache().WaitForCacheSync,
+		}))
+		setupLog.Info("Alertmanager polling enabled", "urls", urls, "interval", alertmanagerPollInterval)
+	}
+	webhookServer := webhook.NewWebhookServer(alertReactionController, webhookPort, webhookOpts...)
+	if poller := webhookServer.Poller(); poller != nil {
+		if err := mgr.Add(poller); err != nil {
+			setupLog.Error(err, "unable to add Alertmanager poller")
+			os.Exit(1)
+		}
 	}
-	webhookServer := webhook.NewWebhookServer(alertReactionController, webhookPort)
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -98,7 +303,9 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// Start webhook server in a goroutine
+	webhookDone := make(chan struct{})
 	go func() {
+		defer close(webhookDone)
 		if err := webhookServer.Start(ctx); err != nil {
 			setupLog.Error(err, "problem running webhook server")
 			cancel()
@@ -114,17 +321,44 @@ func main() {
 		}
 	}()
 
-	// Print webhook configuration
-	setupLog.Info("Alert Reaction Operator started successfully")
-	setupLog.Info("Webhook server configuration:")
-	setupLog.Info(fmt.Sprintf("Webhook endpoint: http://localhost:%s/webhook", webhookPort))
-	setupLog.Info("Add this to your AlertManager configuration:")
-	setupLog.Info(webhookServer.GetWebhookConfig(""))
+	// Print webhook configuration once AlertReactions can be read from the cache
+	go func() {
+		if !mgr.GetCache().WaitForCacheSync(ctx) {
+			return
+		}
+		setupLog.Info("Alert Reaction Operator started successfully")
+		setupLog.Info("Webhook server configuration:")
+		setupLog.Info(fmt.Sprintf("Webhook endpoint: %s", webhookServer.GetWebhookURL("")))
+		setupLog.Info("Add this to your AlertManager configuration:")
+		setupLog.Info(webhookServer.GetWebhookConfig(""))
+	}()
 
 	// Wait for termination signal
 	<-sigChan
 	setupLog.Info("Received termination signal, shutting down...")
 	cancel()
 
+	// Wait for the webhook server to stop and drain queued alerts
+	<-webhookDone
+
 	setupLog.Info("Karo stopped")
 }
+
+// parseNamespacedName parses a "namespace/name" reference, defaulting the namespace to "default"
+func parseNamespacedName(ref string) types.NamespacedName {
+	if namespace, name, ok := strings.Cut(ref, "/"); ok {
+		return types.NamespacedName{Namespace: namespace, Name: name}
+	}
+	return types.NamespacedName{Namespace: "default", Name: ref}
+}
+
+// splitList parses a comma-separated list, ignoring empty items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -10,6 +10,8 @@ kubectl create namespace karo-system --dry-run=client -o yaml | kubectl apply -f
 # Install CRD
 echo "Installing Custom Resource Definition..."
 kubectl apply -f config/crd/karo.io_alertreactions.yaml
+kubectl apply -f config/crd/karo.io_alertsources.yaml
+kubectl apply -f config/crd/karo.io_alerts.yaml
 
 # Install RBAC
 echo "Installing RBAC..."
@@ -22,6 +22,8 @@ kubectl delete -f config/rbac/rbac.yaml --ignore-not-found=true
 # Delete CRD (this will also delete all AlertReaction resources)
 echo "Removing Custom Resource Definition..."
 kubectl delete -f config/crd/karo.io_alertreactions.yaml --ignore-not-found=true
+kubectl delete -f config/crd/karo.io_alertsources.yaml --ignore-not-found=true
+kubectl delete -f config/crd/karo.io_alerts.yaml --ignore-not-found=true
 
 echo ""
 echo "Karo has been uninstalled successfully!"
@@ -0,0 +1,406 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/dudizimber/karo/controllers"
+)
+
+const (
+	// DefaultResolveTimeout is how long an alert without endsAt stays active without being resent,
+	// matching Alertmanager's resolve_timeout default
+	DefaultResolveTimeout = 5 * time.Minute
+
+	// activeAlertSweepInterval is how often active alerts are checked for expiry
+	activeAlertSweepInterval = 30 * time.Second
+
+	// activeAlertStateKey is the ConfigMap key holding the persisted active alerts
+	activeAlertStateKey = "alerts.json"
+)
+
+// ActiveAlertStateConfig configures the persisted state of the alerts received via the Alertmanager API
+type ActiveAlertStateConfig struct {
+	// ConfigMapRef is the ConfigMap the active alerts are stored in. It is created if needed.
+	ConfigMapRef types.NamespacedName
+
+	// Reader, if set, reads the ConfigMap instead of the cached client, so updates are not based
+	// on stale reads. The manager's API reader is a good choice.
+	Reader client.Reader
+}
+
+// WithActiveAlertState persists the alerts firing via the Alertmanager API in a ConfigMap, so
+// alerts that were already firing are not seen as new after a restart or by other replicas
+func WithActiveAlertState(cfg ActiveAlertStateConfig) Option {
+	return func(ws *WebhookServer) {
+		state := &activeAlertState{client: ws.controller.Client, reader: cfg.Reader, ref: cfg.ConfigMapRef}
+		if state.reader == nil {
+			state.reader = ws.controller.Client
+		}
+		ws.activeAlerts.state = state
+	}
+}
+
+// PostableAlert represents an alert sent to the Alertmanager v2 API, as Prometheus does
+type PostableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+}
+
+// activeAlert is an alert that is currently firing
+type activeAlert struct {
+	alert  Alert
+	endsAt time.Time
+}
+
+// alertTransition is a change of an alert between firing and resolved
+type alertTransition struct {
+	alert Alert
+
+	// previous is the active alert replaced by a resolved transition, used for reverting it
+	previous *activeAlert
+}
+
+// activeAlertTracker keeps track of firing alerts by fingerprint, so alerts that are resent
+// on every evaluation only trigger AlertReactions when they start firing or resolve
+type activeAlertTracker struct {
+	mu     sync.Mutex
+	active map[string]*activeAlert
+
+	// state, if set, persists the active alerts, which are then loaded before every change
+	state *activeAlertState
+}
+
+func newActiveAlertTracker() *activeAlertTracker {
+	return &activeAlertTracker{active: make(map[string]*activeAlert)}
+}
+
+// update applies a change to the active alerts. With persisted state, the active alerts are loaded
+// before the change and saved after it if it reports that they changed. The change is retried when
+// another replica updated the state in the meantime, so it must not depend on earlier attempts.
+func (t *activeAlertTracker) update(ctx context.Context, change func() bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.state == nil {
+		change()
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, active, err := t.state.load(ctx)
+		if err != nil {
+			return err
+		}
+		t.active = active
+		if !change() {
+			return nil
+		}
+		return t.state.save(ctx, configMap, t.active)
+	})
+}
+
+// observe records alerts and returns the transitions they caused
+func (t *activeAlertTracker) observe(ctx context.Context, alerts []activeAlert, now time.Time) ([]alertTransition, error) {
+	var transitions []alertTransition
+	err := t.update(ctx, func() bool {
+		transitions = nil
+		changed := false
+		for _, observed := range alerts {
+			alert := observed.alert
+			current, isActive := t.active[alert.Fingerprint]
+
+			if alert.Status == controllers.AlertStatusResolved {
+				if !isActive {
+					// Already resolved, or never seen firing
+					continue
+				}
+				delete(t.active, alert.Fingerprint)
+				transitions = append(transitions, alertTransition{alert: alert, previous: current})
+				changed = true
+				continue
+			}
+
+			if isActive {
+				// Persisted alerts are only refreshed once half of their remaining time passed,
+				// so resends do not rewrite the state on every evaluation
+				if current.endsAt.Sub(now) < observed.endsAt.Sub(now)/2 {
+					changed = true
+				}
+				current.endsAt = observed.endsAt
+				continue
+			}
+			t.active[alert.Fingerprint] = &activeAlert{alert: alert, endsAt: observed.endsAt}
+			transitions = append(transitions, alertTransition{alert: alert})
+			changed = true
+		}
+		return changed
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
+
+// revert undoes transitions whose alerts could not be accepted, so they are seen again when resent
+func (t *activeAlertTracker) revert(ctx context.Context, transitions []alertTransition) error {
+	return t.update(ctx, func() bool {
+		for _, transition := range transitions {
+			if transition.previous != nil {
+				t.active[transition.alert.Fingerprint] = transition.previous
+			} else {
+				delete(t.active, transition.alert.Fingerprint)
+			}
+		}
+		return len(transitions) > 0
+	})
+}
+
+// expire resolves the active alerts that were not resent before their endsAt
+func (t *activeAlertTracker) expire(ctx context.Context, now time.Time) ([]alertTransition, error) {
+	var transitions []alertTransition
+	err := t.update(ctx, func() bool {
+		transitions = nil
+		for fingerprint, current := range t.active {
+			if current.endsAt.After(now) {
+				continue
+			}
+			delete(t.active, fingerprint)
+
+			resolved := current.alert
+			resolved.Status = controllers.AlertStatusResolved
+			resolved.EndsAt = current.endsAt
+			transitions = append(transitions, alertTransition{alert: resolved, previous: current})
+		}
+		return len(transitions) > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(transitions, func(i, j int) bool {
+		return transitions[i].alert.EndsAt.Before(transitions[j].alert.EndsAt)
+	})
+	return transitions, nil
+}
+
+// persistedActiveAlert is the persisted form of an active alert
+type persistedActiveAlert struct {
+	Alert  Alert     `json:"alert"`
+	EndsAt time.Time `json:"endsAt"`
+}
+
+// activeAlertState stores the active alerts as JSON in a ConfigMap shared by all replicas
+type activeAlertState struct {
+	client client.Client
+	reader client.Reader
+	ref    types.NamespacedName
+}
+
+// load reads the active alerts together with the ConfigMap holding them, which is nil if it
+// does not exist yet
+func (s *activeAlertState) load(ctx context.Context) (*corev1.ConfigMap, map[string]*activeAlert, error) {
+	active := make(map[string]*activeAlert)
+
+	var configMap corev1.ConfigMap
+	if err := s.reader.Get(ctx, s.ref, &configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, active, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get active alert state %s: %w", s.ref, err)
+	}
+
+	var persisted map[string]persistedActiveAlert
+	if data := configMap.Data[activeAlertStateKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), &persisted); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode active alert state %s: %w", s.ref, err)
+		}
+	}
+	for fingerprint, alert := range persisted {
+		active[fingerprint] = &activeAlert{alert: alert.Alert, endsAt: alert.EndsAt}
+	}
+	return &configMap, active, nil
+}
+
+// save writes the active alerts, creating the ConfigMap if load did not find it. Writes are
+// based on the loaded ConfigMap, so they fail with a conflict if another replica changed it.
+func (s *activeAlertState) save(ctx context.Context, configMap *corev1.ConfigMap, active map[string]*activeAlert) error {
+	persisted := make(map[string]persistedActiveAlert, len(active))
+	for fingerprint, current := range active {
+		persisted[fingerprint] = persistedActiveAlert{Alert: current.alert, EndsAt: current.endsAt}
+	}
+	data, err := json.Marshal(persisted)
+	if err != nil {
+		return fmt.Errorf("failed to encode active alert state: %w", err)
+	}
+
+	if configMap == nil {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.ref.Name, Namespace: s.ref.Namespace},
+			Data:       map[string]string{activeAlertStateKey: string(data)},
+		}
+		if err := s.client.Create(ctx, configMap); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				// Created by another replica in the meantime: retry based on its state
+				return apierrors.NewConflict(corev1.Resource("configmaps"), s.ref.Name, err)
+			}
+			return fmt.Errorf("failed to create active alert state %s: %w", s.ref, err)
+		}
+		return nil
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[activeAlertStateKey] = string(data)
+	if err := s.client.Update(ctx, configMap); err != nil {
+		if apierrors.IsConflict(err) {
+			return err
+		}
+		return fmt.Errorf("failed to update active alert state %s: %w", s.ref, err)
+	}
+	return nil
+}
+
+// handlePostAlerts implements the Alertmanager v2 POST /api/v2/alerts endpoint
+func (ws *WebhookServer) handlePostAlerts(c *gin.Context) {
+	var postableAlerts []PostableAlert
+
+	if err := c.ShouldBindJSON(&postableAlerts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid JSON: %v", err)})
+		return
+	}
+
+	logger := log.Log.WithValues("alertsCount", len(postableAlerts))
+
+	now := time.Now()
+	alerts := make([]activeAlert, 0, len(postableAlerts))
+	for _, postable := range postableAlerts {
+		alert, endsAt := postable.toAlert(now)
+		alerts = append(alerts, activeAlert{alert: alert, endsAt: endsAt})
+	}
+
+	ctx := c.Request.Context()
+	transitions, err := ws.activeAlerts.observe(ctx, alerts, now)
+	if err != nil {
+		logger.Error(err, "Failed to track active alerts")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(transitions) == 0 {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	logger.Info("Received alert transitions via the Alertmanager API", "transitions", len(transitions))
+
+	batch := ws.transitionBatch(logger, transitions)
+	if len(batch.Alerts) == 0 {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	ws.submit(c, batch)
+	if c.Writer.Status() >= http.StatusMultipleChoices {
+		// Prometheus resends rejected alerts, which must be seen as transitions again
+		if err := ws.activeAlerts.revert(ctx, transitions); err != nil {
+			logger.Error(err, "Failed to revert rejected alert transitions")
+		}
+	}
+}
+
+// expireActiveAlerts resolves active alerts whose endsAt passed without being resent
+func (ws *WebhookServer) expireActiveAlerts(ctx context.Context, now time.Time) {
+	transitions, err := ws.activeAlerts.expire(ctx, now)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to expire active alerts, retrying on the next sweep")
+		return
+	}
+	if len(transitions) == 0 {
+		return
+	}
+
+	logger := log.FromContext(ctx).WithValues("transitions", len(transitions))
+	logger.Info("Resolving expired alerts")
+
+	batch := ws.transitionBatch(logger, transitions)
+	if len(batch.Alerts) == 0 {
+		return
+	}
+	if err := ws.dispatch(ctx, batch); err != nil {
+		logger.Error(err, "Failed to dispatch expired alerts, retrying on the next sweep")
+		if err := ws.activeAlerts.revert(ctx, transitions); err != nil {
+			logger.Error(err, "Failed to revert expired alerts")
+		}
+	}
+}
+
+// sweepActiveAlerts periodically resolves expired alerts until ctx is cancelled
+func (ws *WebhookServer) sweepActiveAlerts(ctx context.Context) {
+	ticker := time.NewTicker(activeAlertSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			ws.expireActiveAlerts(ctx, now)
+		}
+	}
+}
+
+func (ws *WebhookServer) transitionBatch(logger logr.Logger, transitions []alertTransition) *alertBatch {
+	batch := &alertBatch{}
+	for _, transition := range transitions {
+		if queued, ok := ws.newQueuedAlert(logger, transition.alert); ok {
+			batch.Alerts = append(batch.Alerts, queued)
+		}
+	}
+	return batch
+}
+
+// toAlert converts a posted alert. Like Alertmanager, an alert is resolved once its endsAt
+// has passed and active alerts without endsAt expire after DefaultResolveTimeout.
+func (p *PostableAlert) toAlert(now time.Time) (Alert, time.Time) {
+	alert := Alert{
+		Status:       controllers.AlertStatusFiring,
+		Labels:       p.Labels,
+		Annotations:  p.Annotations,
+		StartsAt:     p.StartsAt,
+		GeneratorURL: p.GeneratorURL,
+		Fingerprint:  controllers.LabelsFingerprint(p.Labels),
+	}
+	if alert.StartsAt.IsZero() {
+		alert.StartsAt = now
+	}
+
+	endsAt := p.EndsAt
+	if endsAt.IsZero() {
+		endsAt = now.Add(DefaultResolveTimeout)
+	} else if !endsAt.After(now) {
+		alert.Status = controllers.AlertStatusResolved
+		alert.EndsAt = endsAt
+	}
+
+	return alert, endsAt
+}
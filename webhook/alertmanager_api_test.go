@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+	"github.com/dudizimber/karo/controllers"
+)
+
+func postAlerts(t *testing.T, router *gin.Engine, alerts []PostableAlert) int {
+	t.Helper()
+	payload, err := json.Marshal(alerts)
+	if err != nil {
+		t.Fatalf("Failed to marshal alerts: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "/api/v2/alerts", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func countJobs(t *testing.T, webhookServer *WebhookServer, actionName string) int {
+	t.Helper()
+	var jobs batchv1.JobList
+	if err := webhookServer.controller.List(context.TODO(), &jobs); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	count := 0
+	for _, job := range jobs.Items {
+		if job.Labels["karo/action-name"] == actionName {
+			count++
+		}
+	}
+	return count
+}
+
+func createOnResolvedReaction(t *testing.T, webhookServer *WebhookServer) {
+	t.Helper()
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "cpu-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "HighCPUUsage",
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "scale-up", Image: "busybox:latest"},
+			},
+			OnResolved: []alertreactionv1alpha1.Action{
+				{Name: "scale-down", Image: "busybox:latest"},
+			},
+		},
+	}
+	if err := webhookServer.controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+}
+
+func TestWebhookServer_PostAlertsTriggersOnTransitions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, _ := setupWebhookTest()
+	createOnResolvedReaction(t, webhookServer)
+	router := webhookServer.setupRouter()
+
+	labels := map[string]string{"alertname": "HighCPUUsage", "instance": "node-1"}
+	firing := PostableAlert{
+		Labels:   labels,
+		StartsAt: time.Now().Add(-time.Minute),
+		EndsAt:   time.Now().Add(4 * time.Minute),
+	}
+
+	// Prometheus resends active alerts on every evaluation
+	for i := 0; i < 3; i++ {
+		if code := postAlerts(t, router, []PostableAlert{firing}); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+	}
+	if got := countJobs(t, webhookServer, "scale-up"); got != 1 {
+		t.Errorf("Expected 1 job for the firing transition, got %d", got)
+	}
+
+	resolved := firing
+	resolved.EndsAt = time.Now().Add(-time.Second)
+	for i := 0; i < 2; i++ {
+		if code := postAlerts(t, router, []PostableAlert{resolved}); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+	}
+	if got := countJobs(t, webhookServer, "scale-down"); got != 1 {
+		t.Errorf("Expected 1 job for the resolved transition, got %d", got)
+	}
+}
+
+func TestWebhookServer_ExpireActiveAlerts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, _ := setupWebhookTest()
+	createOnResolvedReaction(t, webhookServer)
+	router := webhookServer.setupRouter()
+
+	// Alerts without endsAt expire after the resolve timeout
+	postAlerts(t, router, []PostableAlert{{Labels: map[string]string{"alertname": "HighCPUUsage"}}})
+
+	webhookServer.expireActiveAlerts(context.TODO(), time.Now())
+	if got := countJobs(t, webhookServer, "scale-down"); got != 0 {
+		t.Fatalf("Expected alert to stay active before the resolve timeout, got %d resolved jobs", got)
+	}
+
+	webhookServer.expireActiveAlerts(context.TODO(), time.Now().Add(DefaultResolveTimeout+time.Second))
+	if got := countJobs(t, webhookServer, "scale-down"); got != 1 {
+		t.Errorf("Expected expired alert to resolve, got %d resolved jobs", got)
+	}
+}
+
+func TestWebhookServer_PostAlertsRevertsRejectedTransitions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, _ := setupWebhookTest()
+	WithQueue(QueueConfig{Size: 1, Workers: 1})(webhookServer)
+	router := webhookServer.setupRouter()
+
+	// No workers are started: the first alert fills the queue and the second is rejected
+	first := PostableAlert{Labels: map[string]string{"alertname": "First"}}
+	second := PostableAlert{Labels: map[string]string{"alertname": "Second"}}
+	if code := postAlerts(t, router, []PostableAlert{first}); code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", code)
+	}
+	if code := postAlerts(t, router, []PostableAlert{second}); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", code)
+	}
+
+	if _, tracked := webhookServer.activeAlerts.active[controllers.LabelsFingerprint(second.Labels)]; tracked {
+		t.Error("Expected rejected alert to be forgotten so the resend is processed")
+	}
+}
+
+func TestWebhookServer_PostAlertsPersistsActiveAlerts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	state := WithActiveAlertState(ActiveAlertStateConfig{
+		ConfigMapRef: types.NamespacedName{Namespace: "default", Name: "karo-active-alerts"},
+	})
+
+	webhookServer, _ := setupWebhookTest()
+	state(webhookServer)
+	createOnResolvedReaction(t, webhookServer)
+
+	firing := PostableAlert{
+		Labels:   map[string]string{"alertname": "HighCPUUsage", "instance": "node-1"},
+		StartsAt: time.Now().Add(-time.Minute),
+		EndsAt:   time.Now().Add(4 * time.Minute),
+	}
+	if code := postAlerts(t, webhookServer.setupRouter(), []PostableAlert{firing}); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if got := countJobs(t, webhookServer, "scale-up"); got != 1 {
+		t.Fatalf("Expected 1 job for the firing transition, got %d", got)
+	}
+
+	var configMap corev1.ConfigMap
+	if err := webhookServer.controller.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "karo-active-alerts"}, &configMap); err != nil {
+		t.Fatalf("Expected active alerts to be persisted: %v", err)
+	}
+
+	// A restarted server, or another replica, shares the persisted state
+	restarted := NewWebhookServer(webhookServer.controller, "9090", state)
+	if code := postAlerts(t, restarted.setupRouter(), []PostableAlert{firing}); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if got := countJobs(t, webhookServer, "scale-up"); got != 1 {
+		t.Errorf("Expected the still firing alert not to trigger again after a restart, got %d jobs", got)
+	}
+
+	resolved := firing
+	resolved.EndsAt = time.Now().Add(-time.Second)
+	if code := postAlerts(t, restarted.setupRouter(), []PostableAlert{resolved}); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if code := postAlerts(t, webhookServer.setupRouter(), []PostableAlert{resolved}); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if got := countJobs(t, webhookServer, "scale-down"); got != 1 {
+		t.Errorf("Expected 1 job for the resolved transition across servers, got %d", got)
+	}
+}
@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func setupAuthTest(t *testing.T, data map[string][]byte) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+	WithAuth(AuthConfig{SecretRef: types.NamespacedName{Namespace: "karo-system", Name: "webhook-auth"}})(webhookServer)
+
+	if data != nil {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook-auth", Namespace: "karo-system"},
+			Data:       data,
+		}
+		if err := controller.Create(context.TODO(), secret); err != nil {
+			t.Fatalf("Failed to create Secret: %v", err)
+		}
+	}
+
+	return webhookServer.setupRouter()
+}
+
+func postWebhook(router *gin.Engine, configure func(*http.Request)) int {
+	req, _ := http.NewRequest("POST", "/webhook", bytes.NewBufferString(`{"version":"4","alerts":[]}`))
+	req.Header.Set("Content-Type", "application/json")
+	if configure != nil {
+		configure(req)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestWebhookServer_Auth(t *testing.T) {
+	secretData := map[string][]byte{
+		AuthSecretTokenKey:    []byte("s3cr3t-token"),
+		AuthSecretUsernameKey: []byte("alertmanager"),
+		AuthSecretPasswordKey: []byte("hunter2"),
+	}
+
+	tests := []struct {
+		name     string
+		data     map[string][]byte
+		setup    func(*http.Request)
+		expected int
+	}{
+		{
+			name:     "no credentials",
+			data:     secretData,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "valid bearer token",
+			data:     secretData,
+			setup:    func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cr3t-token") },
+			expected: http.StatusOK,
+		},
+		{
+			name:     "invalid bearer token",
+			data:     secretData,
+			setup:    func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") },
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "valid basic auth",
+			data:     secretData,
+			setup:    func(r *http.Request) { r.SetBasicAuth("alertmanager", "hunter2") },
+			expected: http.StatusOK,
+		},
+		{
+			name:     "invalid basic auth password",
+			data:     secretData,
+			setup:    func(r *http.Request) { r.SetBasicAuth("alertmanager", "wrong") },
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "basic auth when only token is configured",
+			data:     map[string][]byte{AuthSecretTokenKey: []byte("s3cr3t-token")},
+			setup:    func(r *http.Request) { r.SetBasicAuth("alertmanager", "hunter2") },
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "missing secret fails closed",
+			data:     nil,
+			setup:    func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cr3t-token") },
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := setupAuthTest(t, test.data)
+			if code := postWebhook(router, test.setup); code != test.expected {
+				t.Errorf("Expected status %d, got %d", test.expected, code)
+			}
+		})
+	}
+}
+
+func TestWebhookServer_AuthReloadsRotatedSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+	ref := types.NamespacedName{Namespace: "karo-system", Name: "webhook-auth"}
+	WithAuth(AuthConfig{SecretRef: ref})(webhookServer)
+	router := webhookServer.setupRouter()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: ref.Name, Namespace: ref.Namespace},
+		Data:       map[string][]byte{AuthSecretTokenKey: []byte("old-token")},
+	}
+	if err := controller.Create(context.TODO(), secret); err != nil {
+		t.Fatalf("Failed to create Secret: %v", err)
+	}
+
+	withToken := func(token string) func(*http.Request) {
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	if code := postWebhook(router, withToken("old-token")); code != http.StatusOK {
+		t.Fatalf("Expected old token to be accepted, got %d", code)
+	}
+
+	secret.Data[AuthSecretTokenKey] = []byte("new-token")
+	if err := controller.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("Failed to rotate Secret: %v", err)
+	}
+
+	if code := postWebhook(router, withToken("old-token")); code != http.StatusUnauthorized {
+		t.Errorf("Expected old token to be rejected after rotation, got %d", code)
+	}
+	if code := postWebhook(router, withToken("new-token")); code != http.StatusOK {
+		t.Errorf("Expected new token to be accepted after rotation, got %d", code)
+	}
+}
+
+func TestWebhookServer_HealthIsNotProtected(t *testing.T) {
+	router := setupAuthTest(t, map[string][]byte{AuthSecretTokenKey: []byte("s3cr3t-token")})
+
+	req, _ := http.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected health endpoint to be reachable without credentials, got %d", w.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/dudizimber/karo/controllers"
+)
+
+// AlertExplanation describes how the AlertReactions would react to a single alert
+type AlertExplanation struct {
+	AlertName   string                            `json:"alertName"`
+	Status      string                            `json:"status"`
+	Fingerprint string                            `json:"fingerprint"`
+	Reactions   []controllers.ReactionExplanation `json:"reactions"`
+}
+
+// GroupExplanation describes how the group-mode AlertReactions would react to a notification group
+type GroupExplanation struct {
+	GroupKey  string                            `json:"groupKey"`
+	Receiver  string                            `json:"receiver"`
+	Reactions []controllers.ReactionExplanation `json:"reactions"`
+}
+
+// handleExplainWebhook evaluates an AlertManager webhook payload against all AlertReactions without
+// creating any jobs, returning how each reaction matched and the jobs it would create. It serves
+// webhook requests with ?dryRun=true.
+func (ws *WebhookServer) handleExplainWebhook(c *gin.Context) {
+	webhook, receiver, ok := bindWebhook(c)
+	if !ok {
+		return
+	}
+
+	logger := log.Log.WithValues("receiver", receiver, "alertsCount", len(webhook.Alerts))
+	logger.Info("Explaining webhook from AlertManager")
+
+	batch := ws.webhookBatch(logger, webhook, receiver)
+
+	alerts := []AlertExplanation{}
+	groups := []GroupExplanation{}
+	for _, queued := range batch.Alerts {
+		if queued.Group != nil {
+			reactions, err := ws.controller.ExplainGroup(c.Request.Context(), queued.Group)
+			if err != nil {
+				logger.Error(err, "Failed to explain notification group", "groupKey", queued.Group.GroupKey)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			groups = append(groups, GroupExplanation{
+				GroupKey:  queued.Group.GroupKey,
+				Receiver:  queued.Group.Receiver,
+				Reactions: reactions,
+			})
+			continue
+		}
+
+		reactions, err := ws.controller.ExplainAlert(c.Request.Context(), queued.AlertName, queued.AlertData)
+		if err != nil {
+			logger.Error(err, "Failed to explain alert", "alertName", queued.AlertName)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		status, _ := queued.AlertData["status"].(string)
+		fingerprint, _ := queued.AlertData["fingerprint"].(string)
+		alerts = append(alerts, AlertExplanation{
+			AlertName:   queued.AlertName,
+			Status:      status,
+			Fingerprint: fingerprint,
+			Reactions:   reactions,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts, "groups": groups})
+}
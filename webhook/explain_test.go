@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+	"github.com/dudizimber/karo/controllers"
+)
+
+func TestWebhookServer_HandleExplainWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	if err := controller.Create(context.TODO(), secret); err != nil {
+		t.Fatalf("Failed to create Secret: %v", err)
+	}
+
+	alertReactions := []*alertreactionv1alpha1.AlertReaction{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "production-reaction", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				AlertName: "HighCPUUsage",
+				Matchers: []alertreactionv1alpha1.AlertMatcher{
+					{Name: "environment", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "production"},
+				},
+				Actions: []alertreactionv1alpha1.Action{
+					{
+						Name:  "scale-up",
+						Image: "busybox:latest",
+						Env: []alertreactionv1alpha1.EnvVar{
+							{Name: "INSTANCE", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+								AlertRef: &alertreactionv1alpha1.AlertFieldSelector{FieldPath: "labels.instance"},
+							}},
+							{Name: "API_TOKEN", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+								SecretKeyRef: &alertreactionv1alpha1.SecretKeySelector{Name: "api-credentials", Key: "token"},
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "staging-reaction", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				AlertName: "HighCPUUsage",
+				Matchers: []alertreactionv1alpha1.AlertMatcher{
+					{Name: "environment", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "staging"},
+				},
+				Actions: []alertreactionv1alpha1.Action{
+					{Name: "notify", Image: "busybox:latest"},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "group-reaction", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				AlertName: "HighCPUUsage",
+				Mode:      alertreactionv1alpha1.ReactionModeGroup,
+				Actions: []alertreactionv1alpha1.Action{
+					{Name: "summarize", Image: "busybox:latest"},
+				},
+			},
+		},
+	}
+	for _, alertReaction := range alertReactions {
+		if err := controller.Create(context.TODO(), alertReaction); err != nil {
+			t.Fatalf("Failed to create AlertReaction: %v", err)
+		}
+	}
+
+	payload, err := json.Marshal(AlertManagerWebhook{
+		Version:  "4",
+		Status:   "firing",
+		Receiver: "karo",
+		GroupKey: "{}:{alertname=\"HighCPUUsage\"}",
+		Alerts: []Alert{
+			{
+				Status:      "firing",
+				Labels:      map[string]string{"alertname": "HighCPUUsage", "environment": "production", "instance": "node-1"},
+				Fingerprint: "abc123",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal webhook: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "/webhook?dryRun=true", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	webhookServer.setupRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Alerts []AlertExplanation `json:"alerts"`
+		Groups []GroupExplanation `json:"groups"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Alerts) != 1 || len(response.Alerts[0].Reactions) != 3 {
+		t.Fatalf("Expected 1 alert explained against 3 reactions, got %+v", response.Alerts)
+	}
+
+	reactions := make(map[string]controllers.ReactionExplanation)
+	for _, reaction := range response.Alerts[0].Reactions {
+		reactions[reaction.Name] = reaction
+	}
+
+	staging := reactions["staging-reaction"]
+	if staging.Matched || !staging.AlertNameMatched || len(staging.Jobs) != 0 {
+		t.Errorf("Expected staging reaction to match alertName only, got %+v", staging)
+	}
+	if len(staging.Matchers) != 1 || staging.Matchers[0].Matched || staging.Matchers[0].ActualValue != "production" {
+		t.Errorf("Expected staging matcher to fail on value production, got %+v", staging.Matchers)
+	}
+
+	production := reactions["production-reaction"]
+	if !production.Matched || len(production.Jobs) != 1 {
+		t.Fatalf("Expected production reaction to match with 1 job, got %+v", production)
+	}
+	env := make(map[string]string)
+	for _, envVar := range production.Jobs[0].Spec.Template.Spec.Containers[0].Env {
+		env[envVar.Name] = envVar.Value
+	}
+	if env["INSTANCE"] != "node-1" {
+		t.Errorf("Expected INSTANCE to be rendered, got %q", env["INSTANCE"])
+	}
+	if env["API_TOKEN"] != controllers.RedactedValue {
+		t.Errorf("Expected API_TOKEN to be redacted, got %q", env["API_TOKEN"])
+	}
+
+	if perAlert := reactions["group-reaction"]; !perAlert.Matched || len(perAlert.Jobs) != 0 || perAlert.Reason == "" {
+		t.Errorf("Expected group reaction to match the alert without per-alert jobs, got %+v", perAlert)
+	}
+
+	if len(response.Groups) != 1 || len(response.Groups[0].Reactions) != 1 {
+		t.Fatalf("Expected 1 group explained against the group reaction, got %+v", response.Groups)
+	}
+	group := response.Groups[0].Reactions[0]
+	if group.Name != "group-reaction" || !group.Matched || len(group.Jobs) != 1 {
+		t.Fatalf("Expected group reaction to match with 1 job, got %+v", group)
+	}
+	groupEnv := make(map[string]string)
+	for _, envVar := range group.Jobs[0].Spec.Template.Spec.Containers[0].Env {
+		groupEnv[envVar.Name] = envVar.Value
+	}
+	if groupEnv[controllers.GroupFileEnvVar] == "" {
+		t.Errorf("Expected group job to reference the notification group file, got %+v", groupEnv)
+	}
+
+	var jobs batchv1.JobList
+	if err := controller.List(context.TODO(), &jobs); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 0 {
+		t.Errorf("Expected explain to create no jobs, got %d", len(jobs.Items))
+	}
+}
+
+func TestWebhookServer_HandleWebhook_ReceiverNamedExplain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "explain-receiver", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "HighCPUUsage",
+			Receivers: []string{"explain"},
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "notify", Image: "busybox:latest"},
+			},
+		},
+	}
+	if err := controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	payload, err := json.Marshal(AlertManagerWebhook{
+		Version: "4",
+		Status:  "firing",
+		Alerts: []Alert{
+			{Status: "firing", Labels: map[string]string{"alertname": "HighCPUUsage"}, Fingerprint: "abc123"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal webhook: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "/webhook/explain", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	webhookServer.setupRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var jobs batchv1.JobList
+	if err := controller.List(context.TODO(), &jobs); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Errorf("Expected the explain receiver to create 1 job, got %d", len(jobs.Items))
+	}
+}
@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/dudizimber/karo/controllers"
+)
+
+// GrafanaWebhook represents a Grafana unified alerting webhook contact point payload
+type GrafanaWebhook struct {
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	OrgID             int64             `json:"orgId"`
+	Alerts            []GrafanaAlert    `json:"alerts"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Title             string            `json:"title"`
+	State             string            `json:"state"`
+	Message           string            `json:"message"`
+}
+
+// GrafanaAlert represents a single alert in a Grafana webhook payload
+type GrafanaAlert struct {
+	Alert
+	Values       map[string]float64 `json:"values"`
+	ValueString  string             `json:"valueString"`
+	SilenceURL   string             `json:"silenceURL"`
+	DashboardURL string             `json:"dashboardURL"`
+	PanelURL     string             `json:"panelURL"`
+	ImageURL     string             `json:"imageURL"`
+}
+
+func (ws *WebhookServer) handleGrafanaWebhook(c *gin.Context) {
+	var webhook GrafanaWebhook
+
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid JSON: %v", err)})
+		return
+	}
+
+	// The receiver in the path takes precedence over the one in the payload
+	if receiver := c.Param("receiver"); receiver != "" {
+		webhook.Receiver = receiver
+	}
+
+	logger := log.Log.WithValues("receiver", webhook.Receiver, "orgId", webhook.OrgID, "alertsCount", len(webhook.Alerts))
+	logger.Info("Received webhook from Grafana")
+
+	group := &controllers.AlertGroup{
+		GroupKey:          webhook.GroupKey,
+		Receiver:          webhook.Receiver,
+		Status:            webhook.Status,
+		GroupLabels:       webhook.GroupLabels,
+		CommonLabels:      webhook.CommonLabels,
+		CommonAnnotations: webhook.CommonAnnotations,
+		ExternalURL:       webhook.ExternalURL,
+		TruncatedAlerts:   webhook.TruncatedAlerts,
+	}
+	notification := group.NotificationData()
+
+	batch := &alertBatch{}
+	for _, alert := range webhook.Alerts {
+		queued, ok := ws.newQueuedAlert(logger, alert.Alert)
+		if !ok {
+			continue
+		}
+		addGrafanaFields(queued.AlertData, &webhook, alert)
+		queued.AlertData["notification"] = notification
+		batch.Alerts = append(batch.Alerts, queued)
+	}
+	batch.addGroup(group)
+
+	ws.submit(c, batch)
+}
+
+// addGrafanaFields adds the Grafana-only fields to an alert map built by alertToMap.
+// Values are also added as "values.<refID>" so matchers and field paths can reach them directly.
+func addGrafanaFields(alertMap map[string]interface{}, webhook *GrafanaWebhook, alert GrafanaAlert) {
+	alertMap["receiver"] = webhook.Receiver
+	alertMap["orgId"] = webhook.OrgID
+	alertMap["state"] = webhook.State
+	alertMap["title"] = webhook.Title
+	alertMap["valueString"] = alert.ValueString
+	alertMap["silenceURL"] = alert.SilenceURL
+	alertMap["dashboardURL"] = alert.DashboardURL
+	alertMap["panelURL"] = alert.PanelURL
+	alertMap["imageURL"] = alert.ImageURL
+
+	if alert.Values != nil {
+		values := make(map[string]interface{})
+		for refID, value := range alert.Values {
+			values[refID] = value
+			alertMap["values."+refID] = value
+		}
+		alertMap["values"] = values
+	}
+}
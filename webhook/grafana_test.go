@@ -0,0 +1,200 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+const grafanaPayload = `{
+  "receiver": "karo",
+  "status": "firing",
+  "orgId": 1,
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighLatency", "grafana_folder": "api"},
+      "annotations": {"summary": "p99 latency above threshold"},
+      "startsAt": "2025-10-01T10:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "generatorURL": "https://grafana.example.com/alerting/grafana/abc/view",
+      "fingerprint": "6a2b3c4d5e6f7a8b",
+      "silenceURL": "https://grafana.example.com/alerting/silence/new",
+      "dashboardURL": "https://grafana.example.com/d/latency",
+      "panelURL": "https://grafana.example.com/d/latency?viewPanel=2",
+      "values": {"B": 2.5, "C": 1},
+      "valueString": "[ var='B' labels={} value=2.5 ], [ var='C' labels={} value=1 ]"
+    }
+  ],
+  "groupLabels": {"alertname": "HighLatency"},
+  "commonLabels": {"alertname": "HighLatency"},
+  "commonAnnotations": {},
+  "externalURL": "https://grafana.example.com/",
+  "version": "1",
+  "groupKey": "{}:{alertname=\"HighLatency\"}",
+  "truncatedAlerts": 0,
+  "title": "[FIRING:1] HighLatency api",
+  "state": "alerting",
+  "message": "p99 latency above threshold"
+}`
+
+func TestWebhookServer_HandleGrafanaWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "latency-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "HighLatency",
+			Matchers: []alertreactionv1alpha1.AlertMatcher{
+				{Name: "values.B", Operator: alertreactionv1alpha1.MatchOperatorRegexMatch, Value: "^2\\."},
+			},
+			Actions: []alertreactionv1alpha1.Action{
+				{
+					Name:  "diagnose",
+					Image: "busybox:latest",
+					Env: []alertreactionv1alpha1.EnvVar{
+						{Name: "LATENCY", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+							AlertRef: &alertreactionv1alpha1.AlertFieldSelector{FieldPath: "values.B"},
+						}},
+						{Name: "DASHBOARD", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+							AlertRef: &alertreactionv1alpha1.AlertFieldSelector{FieldPath: "dashboardURL"},
+						}},
+					},
+				},
+			},
+		},
+	}
+	if err := controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "/grafana", bytes.NewBufferString(grafanaPayload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	webhookServer.setupRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var jobs batchv1.JobList
+	if err := controller.List(context.TODO(), &jobs); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Fatalf("Expected 1 job, got %d", len(jobs.Items))
+	}
+
+	env := make(map[string]string)
+	for _, envVar := range jobs.Items[0].Spec.Template.Spec.Containers[0].Env {
+		env[envVar.Name] = envVar.Value
+	}
+	if env["LATENCY"] != "2.5" {
+		t.Errorf("Expected LATENCY=2.5, got %q", env["LATENCY"])
+	}
+	if env["DASHBOARD"] != "https://grafana.example.com/d/latency" {
+		t.Errorf("Expected dashboard URL, got %q", env["DASHBOARD"])
+	}
+}
+
+func TestWebhookServer_HandleGrafanaWebhook_Receiver(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		path    string
+		payload string
+	}{
+		{
+			name:    "receiver in the Grafana path",
+			path:    "/grafana/oncall",
+			payload: grafanaPayload,
+		},
+		{
+			name:    "AlertManager receiver named grafana",
+			path:    "/webhook/grafana",
+			payload: `{"version":"4","status":"firing","alerts":[{"status":"firing","labels":{"alertname":"HighLatency"}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhookServer, controller := setupWebhookTest()
+
+			for _, receiver := range []string{"oncall", "grafana"} {
+				alertReaction := &alertreactionv1alpha1.AlertReaction{
+					ObjectMeta: metav1.ObjectMeta{Name: receiver + "-reaction", Namespace: "default"},
+					Spec: alertreactionv1alpha1.AlertReactionSpec{
+						AlertName: "HighLatency",
+						Receivers: []string{receiver},
+						Actions: []alertreactionv1alpha1.Action{
+							{Name: "notify", Image: "busybox:latest"},
+						},
+					},
+				}
+				if err := controller.Create(context.TODO(), alertReaction); err != nil {
+					t.Fatalf("Failed to create AlertReaction: %v", err)
+				}
+			}
+
+			req, _ := http.NewRequest("POST", tt.path, bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			webhookServer.setupRouter().ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var jobs batchv1.JobList
+			if err := controller.List(context.TODO(), &jobs); err != nil {
+				t.Fatalf("Failed to list jobs: %v", err)
+			}
+			if len(jobs.Items) != 1 {
+				t.Fatalf("Expected 1 job for the path receiver, got %d", len(jobs.Items))
+			}
+		})
+	}
+}
+
+func TestAddGrafanaFields(t *testing.T) {
+	webhookServer, _ := setupWebhookTest()
+
+	webhook := &GrafanaWebhook{OrgID: 3, State: "alerting", Title: "[FIRING:1] DiskFull"}
+	alert := GrafanaAlert{
+		Alert:       Alert{Status: "firing", Labels: map[string]string{"alertname": "DiskFull"}},
+		Values:      map[string]float64{"A": 97},
+		ValueString: "[ var='A' labels={} value=97 ]",
+		SilenceURL:  "https://grafana.example.com/alerting/silence/new",
+	}
+
+	alertMap := webhookServer.alertToMap(alert.Alert)
+	addGrafanaFields(alertMap, webhook, alert)
+
+	expected := map[string]interface{}{
+		"orgId":       int64(3),
+		"state":       "alerting",
+		"title":       "[FIRING:1] DiskFull",
+		"values.A":    float64(97),
+		"valueString": "[ var='A' labels={} value=97 ]",
+		"silenceURL":  "https://grafana.example.com/alerting/silence/new",
+	}
+	for key, value := range expected {
+		if alertMap[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, alertMap[key])
+		}
+	}
+	if values, ok := alertMap["values"].(map[string]interface{}); !ok || values["A"] != float64(97) {
+		t.Errorf("Expected nested values map, got %v", alertMap["values"])
+	}
+}
@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/dudizimber/karo/controllers"
+)
+
+// DefaultHistorySize is the default number of received alerts kept for inspection and replay
+const DefaultHistorySize = 100
+
+// Processing states of alerts in the history
+const (
+	historyStatePending   = "pending"
+	historyStateProcessed = "processed"
+	historyStateFailed    = "failed"
+	historyStateRejected  = "rejected"
+)
+
+// WithHistory keeps the last size received alerts in memory together with their processing
+// outcome, and serves them at GET /alerts. Stored alerts can be re-processed with
+// POST /alerts/:id/replay.
+func WithHistory(size int) Option {
+	return func(ws *WebhookServer) {
+		if size <= 0 {
+			size = DefaultHistorySize
+		}
+		ws.history = &alertHistory{entries: make([]*historyEntry, size)}
+	}
+}
+
+// historyEntry is a received alert together with its processing outcome
+type historyEntry struct {
+	ID          string                   `json:"id"`
+	ReceivedAt  time.Time                `json:"receivedAt"`
+	ProcessedAt *time.Time               `json:"processedAt,omitempty"`
+	AlertName   string                   `json:"alertName"`
+	AlertData   map[string]interface{}   `json:"alertData"`
+	State       string                   `json:"state"`
+	Result      *controllers.AlertResult `json:"result,omitempty"`
+	Error       string                   `json:"error,omitempty"`
+
+	// ReplayOf is the ID of the entry this alert was replayed from
+	ReplayOf string `json:"replayOf,omitempty"`
+}
+
+// alertHistory is a fixed-size ring buffer of the most recently received alerts
+type alertHistory struct {
+	mu      sync.Mutex
+	entries []*historyEntry
+	next    int
+}
+
+// record adds the alerts of a batch to the history and tags them with their entry IDs
+func (h *alertHistory) record(batch *alertBatch) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now().UTC()
+	for i := range batch.Alerts {
+		alert := &batch.Alerts[i]
+		if alert.Group != nil {
+			continue
+		}
+
+		id, err := newHistoryID()
+		if err != nil {
+			return err
+		}
+		alert.historyID = id
+
+		h.entries[h.next] = &historyEntry{
+			ID:         id,
+			ReceivedAt: now,
+			AlertName:  alert.AlertName,
+			AlertData:  alert.AlertData,
+			State:      historyStatePending,
+			ReplayOf:   batch.replayOf,
+		}
+		h.next = (h.next + 1) % len(h.entries)
+	}
+	return nil
+}
+
+// complete records the outcome of processing an alert
+func (h *alertHistory) complete(id string, result *controllers.AlertResult, processErr error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry := h.find(id)
+	if entry == nil {
+		// Evicted while being processed
+		return
+	}
+
+	now := time.Now().UTC()
+	entry.ProcessedAt = &now
+	entry.Result = result
+	entry.State = historyStateProcessed
+	if processErr != nil {
+		entry.State = historyStateFailed
+		entry.Error = processErr.Error()
+	}
+}
+
+// reject marks the alerts of a batch that was not accepted for processing
+func (h *alertHistory) reject(batch *alertBatch, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, alert := range batch.Alerts {
+		if entry := h.find(alert.historyID); entry != nil {
+			entry.State = historyStateRejected
+			entry.Error = err.Error()
+		}
+	}
+}
+
+// get returns a copy of the entry with the given ID
+func (h *alertHistory) get(id string) (historyEntry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry := h.find(id)
+	if entry == nil {
+		return historyEntry{}, false
+	}
+	return *entry, true
+}
+
+// list returns copies of all entries, most recent first
+func (h *alertHistory) list() []historyEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := []historyEntry{}
+	for i := 1; i <= len(h.entries); i++ {
+		entry := h.entries[(h.next-i+len(h.entries))%len(h.entries)]
+		if entry == nil {
+			break
+		}
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+func (h *alertHistory) find(id string) *historyEntry {
+	if id == "" {
+		return nil
+	}
+	for _, entry := range h.entries {
+		if entry != nil && entry.ID == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+func newHistoryID() (string, error) {
+	buffer := make([]byte, 8)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("failed to generate alert history ID: %w", err)
+	}
+	return hex.EncodeToString(buffer), nil
+}
+
+// handleListAlerts returns the most recently received alerts with their processing outcome
+func (ws *WebhookServer) handleListAlerts(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"alerts": ws.history.list()})
+}
+
+// handleReplayAlert processes a stored alert again, for example after fixing an AlertReaction.
+// The replay is recorded as a new entry referencing the original one.
+func (ws *WebhookServer) handleReplayAlert(c *gin.Context) {
+	id := c.Param("id")
+	entry, ok := ws.history.get(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Alert %s not found", id)})
+		return
+	}
+
+	log.Log.Info("Replaying alert", "id", id, "alertName", entry.AlertName)
+
+	batch := &alertBatch{
+		Alerts:   []queuedAlert{{AlertName: entry.AlertName, AlertData: entry.AlertData}},
+		replayOf: id,
+	}
+	ws.submit(c, batch)
+}
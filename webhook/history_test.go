@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+func listAlerts(t *testing.T, router *gin.Engine) []historyEntry {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "/alerts", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Alerts []historyEntry `json:"alerts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response.Alerts
+}
+
+func TestWebhookServer_AlertHistoryAndReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+	WithHistory(10)(webhookServer)
+	router := webhookServer.setupRouter()
+
+	req, _ := http.NewRequest("POST", "/webhook", bytes.NewBuffer(firingPayload(t, "HighCPUUsage")))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	// No AlertReaction exists yet, so nothing matched
+	entries := listAlerts(t, router)
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 alert in the history, got %d", len(entries))
+	}
+	original := entries[0]
+	if original.AlertName != "HighCPUUsage" || original.State != historyStateProcessed {
+		t.Errorf("Unexpected history entry: %+v", original)
+	}
+	if original.Result == nil || len(original.Result.MatchedReactions) != 0 {
+		t.Errorf("Expected no matched reactions, got %+v", original.Result)
+	}
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "cpu-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "HighCPUUsage",
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "scale-up", Image: "busybox:latest"},
+			},
+		},
+	}
+	if err := controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	req, _ = http.NewRequest("POST", "/alerts/"+original.ID+"/replay", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if got := countJobs(t, webhookServer, "scale-up"); got != 1 {
+		t.Errorf("Expected the replay to create 1 job, got %d", got)
+	}
+
+	entries = listAlerts(t, router)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 alerts in the history, got %d", len(entries))
+	}
+	replay := entries[0]
+	if replay.ReplayOf != original.ID {
+		t.Errorf("Expected the most recent entry to be the replay of %s, got %+v", original.ID, replay)
+	}
+	if replay.Result == nil || len(replay.Result.MatchedReactions) != 1 || len(replay.Result.Jobs) != 1 {
+		t.Errorf("Expected the replay to record the matched reaction and job, got %+v", replay.Result)
+	}
+
+	req, _ = http.NewRequest("POST", "/alerts/unknown/replay", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown alert, got %d", w.Code)
+	}
+}
+
+func TestAlertHistory_EvictsOldestEntries(t *testing.T) {
+	history := &alertHistory{entries: make([]*historyEntry, 2)}
+
+	for _, alertName := range []string{"First", "Second", "Third"} {
+		batch := &alertBatch{Alerts: []queuedAlert{{AlertName: alertName}}}
+		if err := history.record(batch); err != nil {
+			t.Fatalf("Failed to record batch: %v", err)
+		}
+	}
+
+	entries := history.list()
+	if len(entries) != 2 || entries[0].AlertName != "Third" || entries[1].AlertName != "Second" {
+		t.Errorf("Expected the 2 most recent alerts, newest first, got %+v", entries)
+	}
+}
@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// webhookRequestsRejected counts webhook requests refused before any alert was processed
+	webhookRequestsRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "karo_webhook_requests_rejected_total",
+			Help: "Number of webhook requests rejected by the webhook server, partitioned by reason",
+		},
+		[]string{"reason"},
+	)
+
+	// alertQueueDepth tracks the number of accepted payloads waiting for a worker
+	alertQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "karo_webhook_queue_depth",
+			Help: "Number of accepted webhook payloads waiting to be processed",
+		},
+	)
+
+	// alertQueueWait observes how long payloads wait in the queue before processing starts
+	alertQueueWait = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "karo_webhook_queue_wait_seconds",
+			Help:    "Time accepted webhook payloads spend in the queue before a worker picks them up",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+	)
+
+	// alertmanagerPollFailures counts failed polls of the Alertmanager API
+	alertmanagerPollFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "karo_alertmanager_poll_failures_total",
+			Help: "Number of failed polls of the Alertmanager API, partitioned by Alertmanager URL",
+		},
+		[]string{"url"},
+	)
+)
+
+func init() {
+	// Register with the controller-runtime registry so the metrics are served on the manager's metrics endpoint
+	metrics.Registry.MustRegister(webhookRequestsRejected, alertQueueDepth, alertQueueWait, alertmanagerPollFailures)
+}
@@ -0,0 +1,315 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/dudizimber/karo/controllers"
+)
+
+const (
+	// DefaultPollInterval is how often Alertmanager is polled for alerts
+	DefaultPollInterval = 30 * time.Second
+
+	// defaultPollTimeout bounds a single request to the Alertmanager API
+	defaultPollTimeout = 10 * time.Second
+
+	// alertStateActive is the state of alerts that are neither silenced nor inhibited
+	alertStateActive = "active"
+)
+
+// PollerConfig configures polling the Alertmanager API for alerts, for clusters Alertmanager cannot reach
+type PollerConfig struct {
+	// URLs are the base URLs of the Alertmanagers to poll, e.g. http://alertmanager:9093.
+	// Alerts reported by several Alertmanagers, like the replicas of an HA setup, are processed once.
+	URLs []string
+
+	// Interval is how often the Alertmanagers are polled
+	Interval time.Duration
+
+	// Client is the HTTP client used for polling. A client with a 10 second timeout is used if unset.
+	Client *http.Client
+
+	// WaitForSync, if set, blocks polling until the client cache is ready to serve reads
+	WaitForSync func(ctx context.Context) bool
+}
+
+// WithPoller periodically fetches the alerts of the given Alertmanagers from GET /api/v2/alerts and
+// processes alerts that started firing or resolved since the previous poll, like webhook notifications
+func WithPoller(cfg PollerConfig) Option {
+	return func(ws *WebhookServer) {
+		if cfg.Interval <= 0 {
+			cfg.Interval = DefaultPollInterval
+		}
+		if cfg.Client == nil {
+			cfg.Client = &http.Client{Timeout: defaultPollTimeout}
+		}
+		ws.poller = newAlertPoller(cfg)
+	}
+}
+
+// GettableAlert represents an alert returned by the Alertmanager v2 API
+type GettableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+	Status       struct {
+		State       string   `json:"state"`
+		SilencedBy  []string `json:"silencedBy"`
+		InhibitedBy []string `json:"inhibitedBy"`
+	} `json:"status"`
+	Receivers []struct {
+		Name string `json:"name"`
+	} `json:"receivers"`
+}
+
+// polledTransition is a change of a polled alert between firing and resolved
+type polledTransition struct {
+	alert    Alert
+	receiver string
+}
+
+// alertPoller diffs the alerts reported by Alertmanagers against the previous polls.
+// It is only used by the polling goroutine and needs no locking.
+type alertPoller struct {
+	cfg PollerConfig
+
+	// startTime is when polling started; alerts that started firing earlier are not processed
+	startTime time.Time
+
+	// reported holds the alerts last returned by each Alertmanager, by fingerprint.
+	// Alertmanagers that cannot be reached keep their previous alerts, so their alerts do not resolve.
+	reported map[string]map[string]GettableAlert
+
+	// firing holds the alerts processed as firing, by fingerprint
+	firing map[string]polledTransition
+}
+
+func newAlertPoller(cfg PollerConfig) *alertPoller {
+	return &alertPoller{
+		cfg:       cfg,
+		startTime: time.Now(),
+		reported:  make(map[string]map[string]GettableAlert),
+		firing:    make(map[string]polledTransition),
+	}
+}
+
+// fetch returns the alerts currently known to an Alertmanager
+func (p *alertPoller) fetch(ctx context.Context, baseURL string) ([]GettableAlert, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/api/v2/alerts?active=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var alerts []GettableAlert
+	if err := json.NewDecoder(resp.Body).Decode(&alerts); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return alerts, nil
+}
+
+// update fetches the alerts of every Alertmanager
+func (p *alertPoller) update(ctx context.Context, logger logr.Logger) {
+	for _, url := range p.cfg.URLs {
+		alerts, err := p.fetch(ctx, url)
+		if err != nil {
+			logger.Error(err, "Failed to poll Alertmanager", "url", url)
+			alertmanagerPollFailures.WithLabelValues(url).Inc()
+			continue
+		}
+
+		reported := make(map[string]GettableAlert, len(alerts))
+		for _, alert := range alerts {
+			if alert.Fingerprint == "" {
+				alert.Fingerprint = controllers.LabelsFingerprint(alert.Labels)
+			}
+			reported[alert.Fingerprint] = alert
+		}
+		p.reported[url] = reported
+	}
+}
+
+// transitions returns the alerts that started firing or resolved since they were last processed.
+// Silenced and inhibited alerts do not start firing, but alerts already firing do not resolve while
+// they are suppressed. Alerts that started before the poller are not processed, so restarts do not
+// run AlertReactions again for alerts that were already firing.
+func (p *alertPoller) transitions(now time.Time) []polledTransition {
+	present := make(map[string]bool)
+	var transitions []polledTransition
+
+	for _, url := range p.cfg.URLs {
+		for fingerprint, alert := range p.reported[url] {
+			present[fingerprint] = true
+			if _, isFiring := p.firing[fingerprint]; isFiring || alert.Status.State != alertStateActive {
+				continue
+			}
+
+			transition := alert.toTransition()
+			p.firing[fingerprint] = transition
+			if !alert.StartsAt.Before(p.startTime) {
+				transitions = append(transitions, transition)
+			}
+		}
+	}
+
+	for fingerprint, transition := range p.firing {
+		if present[fingerprint] {
+			continue
+		}
+		resolved := transition
+		resolved.alert.Status = controllers.AlertStatusResolved
+		resolved.alert.EndsAt = now
+		transitions = append(transitions, resolved)
+	}
+
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].alert.StartsAt.Before(transitions[j].alert.StartsAt)
+	})
+	return transitions
+}
+
+// apply records processed transitions
+func (p *alertPoller) apply(transitions []polledTransition) {
+	for _, transition := range transitions {
+		if transition.alert.Status == controllers.AlertStatusResolved {
+			delete(p.firing, transition.alert.Fingerprint)
+		}
+	}
+}
+
+// revert undoes transitions that could not be processed, so they are seen again on the next poll
+func (p *alertPoller) revert(transitions []polledTransition) {
+	for _, transition := range transitions {
+		if transition.alert.Status == controllers.AlertStatusFiring {
+			delete(p.firing, transition.alert.Fingerprint)
+		}
+	}
+}
+
+// toTransition converts a polled alert into a firing transition
+func (a *GettableAlert) toTransition() polledTransition {
+	transition := polledTransition{
+		alert: Alert{
+			Status:       controllers.AlertStatusFiring,
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			StartsAt:     a.StartsAt,
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  a.Fingerprint,
+		},
+	}
+	if len(a.Receivers) > 0 {
+		transition.receiver = a.Receivers[0].Name
+	}
+	return transition
+}
+
+// pollAlertmanagers processes the alerts that started firing or resolved since the previous poll
+func (ws *WebhookServer) pollAlertmanagers(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	ws.poller.update(ctx, logger)
+	transitions := ws.poller.transitions(time.Now())
+	if len(transitions) == 0 {
+		return
+	}
+
+	logger.Info("Received alert transitions by polling Alertmanager", "transitions", len(transitions))
+
+	batch := &alertBatch{}
+	for _, transition := range transitions {
+		if queued, ok := ws.newQueuedAlert(logger, transition.alert); ok {
+			if transition.receiver != "" {
+				queued.AlertData["receiver"] = transition.receiver
+			}
+			batch.Alerts = append(batch.Alerts, queued)
+		}
+	}
+	if len(batch.Alerts) > 0 {
+		if err := ws.dispatch(ctx, batch); err != nil {
+			logger.Error(err, "Failed to dispatch polled alerts, retrying on the next poll")
+			ws.poller.revert(transitions)
+			return
+		}
+	}
+	ws.poller.apply(transitions)
+}
+
+// Poller returns a manager runnable that polls the configured Alertmanagers, or nil if polling is
+// not configured. It runs only on the elected leader, so replicas do not each process polled alerts.
+func (ws *WebhookServer) Poller() manager.Runnable {
+	if ws.poller == nil {
+		return nil
+	}
+	return &pollerRunnable{ws: ws}
+}
+
+// pollerRunnable runs the poller of a webhook server under the manager's leader election
+type pollerRunnable struct {
+	ws *WebhookServer
+}
+
+// Start implements manager.Runnable
+func (r *pollerRunnable) Start(ctx context.Context) error {
+	r.ws.runPoller(ctx)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (r *pollerRunnable) NeedLeaderElection() bool {
+	return true
+}
+
+// runPoller polls the Alertmanagers until ctx is cancelled
+func (ws *WebhookServer) runPoller(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	// Alerts that started firing before this replica became the leader are not processed
+	ws.poller.startTime = time.Now()
+
+	if ws.poller.cfg.WaitForSync != nil && !ws.poller.cfg.WaitForSync(ctx) {
+		logger.Info("Cache did not sync, not polling Alertmanager")
+		return
+	}
+
+	logger.Info("Polling Alertmanager for alerts", "urls", ws.poller.cfg.URLs, "interval", ws.poller.cfg.Interval)
+
+	ticker := time.NewTicker(ws.poller.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		ws.pollAlertmanagers(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// fakeAlertmanager serves a configurable list of alerts on GET /api/v2/alerts
+type fakeAlertmanager struct {
+	mu     sync.Mutex
+	alerts []GettableAlert
+	fail   bool
+}
+
+func (f *fakeAlertmanager) set(alerts ...GettableAlert) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.alerts = alerts
+}
+
+func (f *fakeAlertmanager) setFailing(fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = fail
+}
+
+func (f *fakeAlertmanager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path != "/api/v2/alerts" || r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if f.fail {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	alerts := f.alerts
+	if alerts == nil {
+		alerts = []GettableAlert{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(alerts)
+}
+
+func polledAlert(fingerprint, state string, startsAt time.Time) GettableAlert {
+	alert := GettableAlert{
+		Labels:      map[string]string{"alertname": "HighCPUUsage", "instance": fingerprint},
+		StartsAt:    startsAt,
+		Fingerprint: fingerprint,
+	}
+	alert.Status.State = state
+	return alert
+}
+
+func TestWebhookServer_PollAlertmanagers(t *testing.T) {
+	webhookServer, _ := setupWebhookTest()
+	createOnResolvedReaction(t, webhookServer)
+
+	primary, replica := &fakeAlertmanager{}, &fakeAlertmanager{}
+	primaryServer, replicaServer := httptest.NewServer(primary), httptest.NewServer(replica)
+	defer primaryServer.Close()
+	defer replicaServer.Close()
+
+	WithPoller(PollerConfig{URLs: []string{primaryServer.URL, replicaServer.URL}})(webhookServer)
+	poll := func() {
+		t.Helper()
+		webhookServer.pollAlertmanagers(context.TODO())
+	}
+
+	startsAt := time.Now().Add(time.Second)
+	stale := polledAlert("stale", alertStateActive, time.Now().Add(-time.Hour))
+	firing := polledAlert("node-1", alertStateActive, startsAt)
+	silenced := polledAlert("node-2", "suppressed", startsAt)
+
+	// Alerts that fired before the poller started are not processed, and
+	// alerts reported by several Alertmanagers are processed once
+	primary.set(stale, firing, silenced)
+	replica.set(firing)
+	poll()
+	poll()
+	if got := countJobs(t, webhookServer, "scale-up"); got != 1 {
+		t.Fatalf("Expected 1 firing job, got %d", got)
+	}
+
+	// Alerts stay firing while an Alertmanager that reported them cannot be reached
+	primary.set(stale, silenced)
+	replica.setFailing(true)
+	poll()
+	if got := countJobs(t, webhookServer, "scale-down"); got != 0 {
+		t.Fatalf("Expected no resolved job while the replica is unreachable, got %d", got)
+	}
+
+	// Alerts resolve once no Alertmanager reports them anymore
+	replica.setFailing(false)
+	replica.set()
+	poll()
+	if got := countJobs(t, webhookServer, "scale-down"); got != 1 {
+		t.Fatalf("Expected 1 resolved job, got %d", got)
+	}
+
+	// Silenced alerts fire once the silence expires, and stale alerts resolve like any other
+	silenced.Status.State = alertStateActive
+	primary.set(silenced)
+	poll()
+	if got := countJobs(t, webhookServer, "scale-up"); got != 2 {
+		t.Errorf("Expected a firing job for the unsilenced alert, got %d", got)
+	}
+	if got := countJobs(t, webhookServer, "scale-down"); got != 2 {
+		t.Errorf("Expected a resolved job for the stale alert, got %d", got)
+	}
+}
+
+func TestWebhookServer_PollAlertmanagersRevertsRejectedTransitions(t *testing.T) {
+	webhookServer, _ := setupWebhookTest()
+	WithQueue(QueueConfig{Size: 1, Workers: 1})(webhookServer)
+
+	alertmanager := &fakeAlertmanager{}
+	server := httptest.NewServer(alertmanager)
+	defer server.Close()
+	WithPoller(PollerConfig{URLs: []string{server.URL}})(webhookServer)
+
+	// No workers are started: the first poll fills the queue and the second is rejected
+	alertmanager.set(polledAlert("first", alertStateActive, time.Now().Add(time.Second)))
+	webhookServer.pollAlertmanagers(context.TODO())
+
+	second := polledAlert("second", alertStateActive, time.Now().Add(time.Second))
+	alertmanager.set(second)
+	webhookServer.pollAlertmanagers(context.TODO())
+
+	if _, firing := webhookServer.poller.firing[second.Fingerprint]; firing {
+		t.Error("Expected rejected alert to be forgotten so the next poll processes it")
+	}
+	if _, firing := webhookServer.poller.firing["first"]; !firing {
+		t.Error("Expected the resolution of the first alert to be retried on the next poll")
+	}
+}
+
+func TestWebhookServer_PollerNeedsLeaderElection(t *testing.T) {
+	webhookServer, _ := setupWebhookTest()
+	if webhookServer.Poller() != nil {
+		t.Error("Expected no poller without Alertmanager URLs")
+	}
+
+	WithPoller(PollerConfig{URLs: []string{"http://alertmanager:9093"}})(webhookServer)
+	poller, ok := webhookServer.Poller().(manager.LeaderElectionRunnable)
+	if !ok || !poller.NeedLeaderElection() {
+		t.Error("Expected the poller to run only on the elected leader")
+	}
+}
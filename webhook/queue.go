@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/dudizimber/karo/controllers"
+)
+
+// Defaults for the asynchronous alert queue
+const (
+	DefaultQueueSize           = 1000
+	DefaultQueueWorkers        = 4
+	DefaultShutdownGracePeriod = 20 * time.Second
+)
+
+// Reasons reported when a request cannot be queued or persisted
+const (
+	queueReasonFull         = "queue_full"
+	queueReasonShuttingDown = "shutting_down"
+	spoolReasonWriteFailed  = "spool_write_failed"
+)
+
+var (
+	errQueueFull   = errors.New("alert queue is full")
+	errQueueClosed = errors.New("alert queue is closed")
+	errSpoolWrite  = errors.New("failed to spool alerts")
+)
+
+// QueueConfig configures asynchronous processing of accepted webhook payloads
+type QueueConfig struct {
+	// Size is the maximum number of payloads waiting to be processed
+	Size int
+
+	// Workers is the number of payloads processed concurrently
+	Workers int
+
+	// ShutdownGracePeriod bounds how long queued payloads may keep draining after shutdown starts
+	ShutdownGracePeriod time.Duration
+}
+
+// WithQueue acknowledges webhook payloads as soon as they are queued (202 Accepted) and processes
+// them in the background. Requests are rejected with 503 when the queue is full.
+func WithQueue(cfg QueueConfig) Option {
+	return func(ws *WebhookServer) {
+		if cfg.Size <= 0 {
+			cfg.Size = DefaultQueueSize
+		}
+		if cfg.Workers <= 0 {
+			cfg.Workers = DefaultQueueWorkers
+		}
+		if cfg.ShutdownGracePeriod <= 0 {
+			cfg.ShutdownGracePeriod = DefaultShutdownGracePeriod
+		}
+		ws.queue = newAlertQueue(cfg)
+	}
+}
+
+// alertBatch is the unit of work accepted from a single webhook request
+type alertBatch struct {
+	Alerts []queuedAlert `json:"alerts"`
+
+	enqueuedAt time.Time
+	spoolID    string
+
+	// replayOf is the history entry ID the batch replays
+	replayOf string
+}
+
+// queuedAlert is a single alert waiting to be processed. If Group is set, it is instead the
+// notification group of the payload, waiting to be processed by group-mode AlertReactions.
+type queuedAlert struct {
+	AlertName string                 `json:"alertName,omitempty"`
+	AlertData map[string]interface{} `json:"alertData,omitempty"`
+
+	Group *controllers.AlertGroup `json:"group,omitempty"`
+
+	historyID string
+}
+
+// addGroup adds the notification group of the alerts in the batch, so group-mode AlertReactions
+// can process them together
+func (b *alertBatch) addGroup(group *controllers.AlertGroup) {
+	if len(b.Alerts) == 0 {
+		return
+	}
+	for _, alert := range b.Alerts {
+		group.Alerts = append(group.Alerts, alert.AlertData)
+	}
+	b.Alerts = append(b.Alerts, queuedAlert{Group: group})
+}
+
+// alertQueue is a bounded in-memory queue served by a fixed pool of workers
+type alertQueue struct {
+	cfg   QueueConfig
+	items chan *alertBatch
+
+	mu     sync.RWMutex
+	closed bool
+
+	workers sync.WaitGroup
+}
+
+func newAlertQueue(cfg QueueConfig) *alertQueue {
+	return &alertQueue{
+		cfg:   cfg,
+		items: make(chan *alertBatch, cfg.Size),
+	}
+}
+
+// start launches the workers. Each worker hands batches to process until the queue is closed and drained.
+func (q *alertQueue) start(ctx context.Context, process func(context.Context, *alertBatch)) {
+	for i := 0; i < q.cfg.Workers; i++ {
+		q.workers.Add(1)
+		go func() {
+			defer q.workers.Done()
+			for batch := range q.items {
+				alertQueueDepth.Dec()
+				alertQueueWait.Observe(time.Since(batch.enqueuedAt).Seconds())
+				process(ctx, batch)
+			}
+		}()
+	}
+}
+
+// enqueue adds a batch without blocking. It fails with errQueueFull when no capacity is left
+// and with errQueueClosed once shutdown has started.
+func (q *alertQueue) enqueue(batch *alertBatch) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return errQueueClosed
+	}
+
+	batch.enqueuedAt = time.Now()
+	select {
+	case q.items <- batch:
+		alertQueueDepth.Inc()
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// shutdown stops accepting work and waits for queued batches to drain. If the grace period
+// expires first, cancel is called to abort in-flight processing.
+func (q *alertQueue) shutdown(cancel context.CancelFunc) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.items)
+	q.mu.Unlock()
+
+	logger := log.Log.WithValues("pending", len(q.items))
+	logger.Info("Draining alert queue", "gracePeriod", q.cfg.ShutdownGracePeriod)
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.Info("Alert queue drained")
+	case <-time.After(q.cfg.ShutdownGracePeriod):
+		logger.Info("Alert queue grace period expired, cancelling in-flight work", "remaining", len(q.items))
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+)
+
+func firingPayload(t *testing.T, alertName string) []byte {
+	t.Helper()
+	payload, err := json.Marshal(AlertManagerWebhook{
+		Version:  "4",
+		Status:   "firing",
+		Receiver: "karo",
+		Alerts: []Alert{
+			{
+				Status:      "firing",
+				Labels:      map[string]string{"alertname": alertName},
+				StartsAt:    time.Now(),
+				Fingerprint: "abc123",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal webhook: %v", err)
+	}
+	return payload
+}
+
+func TestWebhookServer_QueuedWebhookIsAccepted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+	WithQueue(QueueConfig{Size: 10, Workers: 2})(webhookServer)
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "queued-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "HighCPUUsage",
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "scale-up", Image: "busybox:latest", Command: []string{"echo", "scaling up"}},
+			},
+		},
+	}
+	if err := controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	webhookServer.queue.start(ctx, webhookServer.handleBatch)
+
+	req, _ := http.NewRequest("POST", "/webhook", bytes.NewBuffer(firingPayload(t, "HighCPUUsage")))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	webhookServer.setupRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+
+	// Draining the queue waits for the accepted payload to be processed
+	webhookServer.queue.shutdown(cancel)
+
+	var jobs batchv1.JobList
+	if err := controller.List(context.TODO(), &jobs); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Errorf("Expected 1 job after the queue drained, got %d", len(jobs.Items))
+	}
+}
+
+func TestWebhookServer_QueueFull(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, _ := setupWebhookTest()
+	WithQueue(QueueConfig{Size: 1, Workers: 1})(webhookServer)
+	router := webhookServer.setupRouter()
+
+	// No workers are started, so the first payload occupies the only slot
+	codes := make([]int, 0, 2)
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("POST", "/webhook", bytes.NewBuffer(firingPayload(t, "HighCPUUsage")))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+
+	if codes[0] != http.StatusAccepted {
+		t.Errorf("Expected first payload to be accepted, got %d", codes[0])
+	}
+	if codes[1] != http.StatusServiceUnavailable {
+		t.Errorf("Expected second payload to be rejected with 503, got %d", codes[1])
+	}
+}
+
+func TestAlertQueue_ShutdownDrainsAndRejects(t *testing.T) {
+	queue := newAlertQueue(QueueConfig{Size: 10, Workers: 2, ShutdownGracePeriod: 5 * time.Second})
+
+	for i := 0; i < 5; i++ {
+		if err := queue.enqueue(&alertBatch{}); err != nil {
+			t.Fatalf("Unexpected enqueue error: %v", err)
+		}
+	}
+
+	var processed atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue.start(ctx, func(context.Context, *alertBatch) {
+		time.Sleep(10 * time.Millisecond)
+		processed.Add(1)
+	})
+
+	queue.shutdown(cancel)
+
+	if got := processed.Load(); got != 5 {
+		t.Errorf("Expected 5 processed batches after drain, got %d", got)
+	}
+	if err := queue.enqueue(&alertBatch{}); err != errQueueClosed {
+		t.Errorf("Expected errQueueClosed after shutdown, got %v", err)
+	}
+}
+
+func TestAlertQueue_ShutdownGracePeriodCancelsWork(t *testing.T) {
+	queue := newAlertQueue(QueueConfig{Size: 1, Workers: 1, ShutdownGracePeriod: 50 * time.Millisecond})
+	if err := queue.enqueue(&alertBatch{}); err != nil {
+		t.Fatalf("Unexpected enqueue error: %v", err)
+	}
+
+	var cancelled atomic.Bool
+	ctx, cancel := context.WithCancel(context.Background())
+	queue.start(ctx, func(ctx context.Context, _ *alertBatch) {
+		<-ctx.Done()
+		cancelled.Store(true)
+	})
+
+	queue.shutdown(cancel)
+
+	if !cancelled.Load() {
+		t.Error("Expected in-flight work to be cancelled after the grace period")
+	}
+}
@@ -2,13 +2,17 @@ package webhook
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
 	"github.com/dudizimber/karo/controllers"
 )
 
@@ -41,32 +45,41 @@ type Alert struct {
 type WebhookServer struct {
 	controller *controllers.AlertReactionReconciler
 	port       string
+	auth       *AuthConfig
+
+	signature      *SignatureConfig
+	seenSignatures *replayCache
+
+	tls         *TLSConfig
+	certWatcher *certwatcher.CertWatcher
+
+	queue *alertQueue
+	spool *alertSpool
+
+	activeAlerts *activeAlertTracker
+	history      *alertHistory
+	poller       *alertPoller
 }
 
+// Option configures optional behaviour of the webhook server
+type Option func(*WebhookServer)
+
 // NewWebhookServer creates a new webhook server
-func NewWebhookServer(controller *controllers.AlertReactionReconciler, port string) *WebhookServer {
-	return &WebhookServer{
-		controller: controller,
-		port:       port,
+func NewWebhookServer(controller *controllers.AlertReactionReconciler, port string, opts ...Option) *WebhookServer {
+	ws := &WebhookServer{
+		controller:   controller,
+		port:         port,
+		activeAlerts: newActiveAlertTracker(),
 	}
+	for _, opt := range opts {
+		opt(ws)
+	}
+	return ws
 }
 
 // Start starts the webhook server
 func (ws *WebhookServer) Start(ctx context.Context) error {
-	router := gin.New()
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
-	})
-
-	// Webhook endpoint for AlertManager
-	router.POST("/webhook", ws.handleWebhook)
-
-	// Webhook endpoint with receiver name (for multiple receivers)
-	router.POST("/webhook/:receiver", ws.handleWebhook)
+	router := ws.setupRouter()
 
 	server := &http.Server{
 		Addr:              ":" + ws.port,
@@ -75,11 +88,50 @@ func (ws *WebhookServer) Start(ctx context.Context) error {
 	}
 
 	logger := log.FromContext(ctx)
-	logger.Info("Starting webhook server", "port", ws.port)
+
+	if ws.tls != nil {
+		tlsConfig, err := ws.buildTLSConfig(ctx)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = tlsConfig
+	}
+
+	// Workers keep running past ctx cancellation so queued alerts can drain during shutdown
+	workerCtx, cancelWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWorkers()
+	// Spooled payloads are listed before requests are accepted, so payloads spooled by this run
+	// are not replayed as leftovers of a previous one
+	var leftovers []*spoolEntry
+	if ws.spool != nil {
+		if err := ws.spool.init(); err != nil {
+			return fmt.Errorf("failed to initialize alert spool: %w", err)
+		}
+		var err error
+		if leftovers, err = ws.spool.list(); err != nil {
+			return fmt.Errorf("failed to list spooled webhook payloads: %w", err)
+		}
+	}
+	if ws.queue != nil {
+		ws.queue.start(workerCtx, ws.handleBatch)
+	}
+	if len(leftovers) > 0 {
+		go ws.replaySpool(workerCtx, leftovers)
+	}
+	go ws.sweepActiveAlerts(ctx)
+
+	logger.Info("Starting webhook server", "port", ws.port, "tls", ws.tls != nil)
 
 	// Start server in a goroutine
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if ws.tls != nil {
+			// Certificates are served from TLSConfig.GetCertificate
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Error(err, "Failed to start webhook server")
 		}
 	}()
@@ -93,47 +145,291 @@ func (ws *WebhookServer) Start(ctx context.Context) error {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	return server.Shutdown(shutdownCtx)
+	err := server.Shutdown(shutdownCtx)
+
+	if ws.queue != nil {
+		ws.queue.shutdown(cancelWorkers)
+	}
+
+	return err
+}
+
+// setupRouter registers all webhook server routes
+func (ws *WebhookServer) setupRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Logger())
+	router.Use(gin.Recovery())
+
+	// Health check endpoint
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	})
+
+	// Webhook endpoint for AlertManager. With ?dryRun=true, it explains which AlertReactions would
+	// react instead.
+	router.POST("/webhook", ws.protected(ws.handleWebhook)...)
+
+	// Webhook endpoint with receiver name (for multiple receivers)
+	router.POST("/webhook/:receiver", ws.protected(ws.handleWebhook)...)
+
+	// Webhook endpoints for Grafana unified alerting contact points
+	router.POST("/grafana", ws.protected(ws.handleGrafanaWebhook)...)
+	router.POST("/grafana/:receiver", ws.protected(ws.handleGrafanaWebhook)...)
+
+	// Alertmanager-compatible ingestion API, so Prometheus can send alerts directly
+	router.POST("/api/v2/alerts", ws.protected(ws.handlePostAlerts)...)
+
+	// Webhook endpoints for generic JSON payloads, mapped by AlertSources
+	router.POST("/sources/:namespace/*path", ws.protected(ws.handleSourceWebhook)...)
+
+	// Inspection and replay of recently received alerts
+	if ws.history != nil {
+		router.GET("/alerts", ws.protected(ws.handleListAlerts)...)
+		router.POST("/alerts/:id/replay", ws.protected(ws.handleReplayAlert)...)
+	}
+
+	// Inspection of payloads that were accepted but not fully processed yet
+	if ws.spool != nil {
+		router.GET("/spool", ws.protected(ws.handleListSpool)...)
+	}
+
+	return router
+}
+
+// protected wraps an alert ingestion handler with the configured request checks
+func (ws *WebhookServer) protected(handler gin.HandlerFunc) []gin.HandlerFunc {
+	var handlers []gin.HandlerFunc
+	if ws.auth != nil {
+		handlers = append(handlers, ws.authenticate())
+	}
+	if ws.signature != nil {
+		handlers = append(handlers, ws.verifySignature())
+	}
+	return append(handlers, handler)
 }
 
 func (ws *WebhookServer) handleWebhook(c *gin.Context) {
+	if c.Query("dryRun") == "true" {
+		ws.handleExplainWebhook(c)
+		return
+	}
+
+	webhook, receiver, ok := bindWebhook(c)
+	if !ok {
+		return
+	}
+
+	logger := log.Log.WithValues("receiver", receiver, "alertsCount", len(webhook.Alerts))
+	logger.Info("Received webhook from AlertManager")
+
+	ws.submit(c, ws.webhookBatch(logger, webhook, receiver))
+}
+
+// bindWebhook decodes an AlertManager webhook payload and determines its receiver.
+// It responds with an error and reports false if the payload is invalid.
+func bindWebhook(c *gin.Context) (*AlertManagerWebhook, string, bool) {
 	var webhook AlertManagerWebhook
 
 	if err := c.ShouldBindJSON(&webhook); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid JSON: %v", err)})
-		return
+		return nil, "", false
 	}
 
-	logger := log.Log.WithValues("receiver", webhook.Receiver, "alertsCount", len(webhook.Alerts))
-	logger.Info("Received webhook from AlertManager")
+	// The receiver in the path takes precedence over the one in the payload
+	receiver := c.Param("receiver")
+	if receiver == "" {
+		receiver = webhook.Receiver
+	}
 
-	// Process each alert
+	return &webhook, receiver, true
+}
+
+// webhookBatch converts the alerts of an AlertManager webhook for processing
+func (ws *WebhookServer) webhookBatch(logger logr.Logger, webhook *AlertManagerWebhook, receiver string) *alertBatch {
+	group := &controllers.AlertGroup{
+		GroupKey:          webhook.GroupKey,
+		Receiver:          receiver,
+		Status:            webhook.Status,
+		GroupLabels:       webhook.GroupLabels,
+		CommonLabels:      webhook.CommonLabels,
+		CommonAnnotations: webhook.CommonAnnotations,
+		ExternalURL:       webhook.ExternalURL,
+		TruncatedAlerts:   webhook.TruncatedAlerts,
+	}
+	notification := group.NotificationData()
+
+	batch := &alertBatch{}
 	for _, alert := range webhook.Alerts {
-		if alert.Status != "firing" {
-			logger.Info("Skipping non-firing alert", "alertName", alert.Labels["alertname"], "status", alert.Status)
-			continue
+		if queued, ok := ws.newQueuedAlert(logger, alert); ok {
+			queued.AlertData["receiver"] = receiver
+			queued.AlertData["notification"] = notification
+			batch.Alerts = append(batch.Alerts, queued)
 		}
+	}
+	batch.addGroup(group)
 
-		alertName := alert.Labels["alertname"]
-		if alertName == "" {
-			logger.Info("Skipping alert without alertname label")
-			continue
+	return batch
+}
+
+// newQueuedAlert converts an alert for processing. It reports false for alerts that cannot be processed.
+func (ws *WebhookServer) newQueuedAlert(logger logr.Logger, alert Alert) (queuedAlert, bool) {
+	// Resolved alerts are passed on so AlertReactions can run their onResolved actions
+	if alert.Status != controllers.AlertStatusFiring && alert.Status != controllers.AlertStatusResolved {
+		logger.Info("Skipping alert with unknown status", "alertName", alert.Labels["alertname"], "status", alert.Status)
+		return queuedAlert{}, false
+	}
+
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		logger.Info("Skipping alert without alertname label")
+		return queuedAlert{}, false
+	}
+
+	// Convert alert to map for processing
+	return queuedAlert{
+		AlertName: alertName,
+		AlertData: ws.alertToMap(alert),
+	}, true
+}
+
+// submit processes an accepted batch of alerts, either inline or through the work queue,
+// and writes the HTTP response
+func (ws *WebhookServer) submit(c *gin.Context, batch *alertBatch) {
+	err := ws.dispatch(context.Background(), batch)
+
+	switch {
+	case err == nil && ws.queue == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook processed successfully"})
+	case err == nil:
+		c.JSON(http.StatusAccepted, gin.H{"message": "Webhook accepted for processing"})
+	case errors.Is(err, errSpoolWrite):
+		log.Log.Error(err, "Failed to spool webhook payload")
+		webhookRequestsRejected.WithLabelValues(spoolReasonWriteFailed).Inc()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to persist alerts, retry later"})
+	case errors.Is(err, errQueueFull):
+		webhookRequestsRejected.WithLabelValues(queueReasonFull).Inc()
+		log.Log.Info("Rejected webhook request, alert queue is full", "alertsCount", len(batch.Alerts))
+		c.Header("Retry-After", "10")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Alert queue is full, retry later"})
+	default:
+		webhookRequestsRejected.WithLabelValues(queueReasonShuttingDown).Inc()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Webhook server is shutting down"})
+	}
+}
+
+// dispatch persists a batch and hands it to processing. Without a queue the batch is processed
+// before dispatch returns.
+func (ws *WebhookServer) dispatch(ctx context.Context, batch *alertBatch) error {
+	if ws.history != nil {
+		if err := ws.history.record(batch); err != nil {
+			log.Log.Error(err, "Failed to record alerts in the history")
 		}
+	}
 
-		// Convert alert to map for processing
-		alertData := ws.alertToMap(alert)
+	err := ws.accept(ctx, batch)
+	if err != nil && ws.history != nil {
+		ws.history.reject(batch, err)
+	}
+	return err
+}
+
+// accept persists a batch and hands it to processing
+func (ws *WebhookServer) accept(ctx context.Context, batch *alertBatch) error {
+	// Persist the batch before acknowledging it so it survives a restart
+	if ws.spool != nil {
+		if err := ws.spool.add(batch); err != nil {
+			return fmt.Errorf("%w: %v", errSpoolWrite, err)
+		}
+	}
 
-		// Process the alert
-		ctx := context.Background()
-		if err := ws.controller.ProcessAlert(ctx, alertName, alertData); err != nil {
-			logger.Error(err, "Failed to process alert", "alertName", alertName)
+	if ws.queue == nil {
+		ws.handleBatch(ctx, batch)
+		return nil
+	}
+
+	err := ws.queue.enqueue(batch)
+	if err != nil && ws.spool != nil {
+		// The sender retries rejected payloads, so the spooled copy is not needed
+		if spoolErr := ws.spool.discard(batch); spoolErr != nil {
+			log.Log.Error(spoolErr, "Failed to discard spooled webhook payload", "spoolID", batch.spoolID)
+		}
+	}
+	return err
+}
+
+// handleBatch processes a batch and records the outcome in the spool
+func (ws *WebhookServer) handleBatch(ctx context.Context, batch *alertBatch) {
+	failed, err := ws.processBatch(ctx, batch)
+
+	if ws.spool != nil && batch.spoolID != "" {
+		if spoolErr := ws.spool.complete(batch, failed, err); spoolErr != nil {
+			log.Log.Error(spoolErr, "Failed to update spooled webhook payload", "spoolID", batch.spoolID)
+		}
+	}
+}
+
+// processBatch runs every alert of a batch through the controller.
+// It returns the alerts that could not be processed and the last processing error.
+func (ws *WebhookServer) processBatch(ctx context.Context, batch *alertBatch) ([]queuedAlert, error) {
+	var failed []queuedAlert
+	var lastErr error
+
+	for _, alert := range batch.Alerts {
+		if alert.Group != nil {
+			if err := ws.controller.ProcessGroup(ctx, alert.Group); err != nil {
+				log.Log.Error(err, "Failed to process notification group", "groupKey", alert.Group.GroupKey)
+				failed = append(failed, alert)
+				lastErr = err
+			}
+			continue
+		}
+
+		result, err := ws.controller.ProcessAlertWithResult(ctx, alert.AlertName, alert.AlertData)
+		if ws.history != nil {
+			ws.history.complete(alert.historyID, result, err)
+		}
+		if err != nil {
+			log.Log.Error(err, "Failed to process alert", "alertName", alert.AlertName)
+			failed = append(failed, alert)
+			lastErr = err
 			// Continue processing other alerts even if one fails
 		} else {
-			logger.Info("Successfully processed alert", "alertName", alertName)
+			log.Log.Info("Successfully processed alert", "alertName", alert.AlertName)
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Webhook processed successfully"})
+	return failed, lastErr
+}
+
+// replaySpool re-processes the spooled batches left over from a previous run
+func (ws *WebhookServer) replaySpool(ctx context.Context, entries []*spoolEntry) {
+	logger := log.FromContext(ctx)
+
+	if ws.spool.waitForSync != nil && !ws.spool.waitForSync(ctx) {
+		logger.Info("Cache did not sync, skipping replay of spooled webhook payloads")
+		return
+	}
+
+	logger.Info("Replaying spooled webhook payloads", "count", len(entries))
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		ws.handleBatch(ctx, entry.Batch)
+	}
+}
+
+// handleListSpool returns the spooled payloads that have not been fully processed yet
+func (ws *WebhookServer) handleListSpool(c *gin.Context) {
+	entries, err := ws.spool.list()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []*spoolEntry{}
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
 }
 
 func (ws *WebhookServer) alertToMap(alert Alert) map[string]interface{} {
@@ -175,15 +471,52 @@ func (ws *WebhookServer) alertToMap(alert Alert) map[string]interface{} {
 // GetWebhookURL returns the webhook URL that should be configured in AlertManager
 func (ws *WebhookServer) GetWebhookURL(baseURL string) string {
 	if baseURL == "" {
-		baseURL = "http://localhost:" + ws.port
+		scheme := "http"
+		if ws.tls != nil {
+			scheme = "https"
+		}
+		baseURL = scheme + "://localhost:" + ws.port
 	}
 	return baseURL + "/webhook"
 }
 
-// GetWebhookConfig returns an example AlertManager configuration
+// sendResolved reports whether any AlertReaction defines onResolved actions or cancels jobs on
+// resolve, in which case AlertManager has to notify the webhook about resolved alerts too
+func (ws *WebhookServer) sendResolved() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var alertReactionList alertreactionv1alpha1.AlertReactionList
+	if err := ws.controller.List(ctx, &alertReactionList); err != nil {
+		log.Log.Error(err, "Failed to list AlertReactions for the example configuration")
+		return false
+	}
+
+	for i := range alertReactionList.Items {
+		if controllers.HandlesResolvedAlerts(&alertReactionList.Items[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetWebhookConfig returns an example AlertManager configuration. AlertReactions are read from the
+// controller's client, so with a cached client it must be called once the cache has synced.
 func (ws *WebhookServer) GetWebhookConfig(baseURL string) string {
 	webhookURL := ws.GetWebhookURL(baseURL)
 
+	tlsConfig := ""
+	if ws.tls != nil {
+		tlsConfig = `
+      tls_config:
+        ca_file: /etc/alertmanager/karo/ca.crt`
+		if ws.tls.ClientCAFile != "" {
+			tlsConfig += `
+        cert_file: /etc/alertmanager/karo/tls.crt
+        key_file: /etc/alertmanager/karo/tls.key`
+		}
+	}
+
 	config := fmt.Sprintf(`# AlertManager configuration example
 # Add this to your AlertManager configuration file
 
@@ -198,11 +531,11 @@ receivers:
 - name: 'karo'
   webhook_configs:
   - url: '%s'
-    send_resolved: false
+    send_resolved: %t
     http_config:
-      timeout: 10s
+      timeout: 10s%s
     max_alerts: 0  # Send all alerts, no limit
-`, webhookURL)
+`, webhookURL, ws.sendResolved(), tlsConfig)
 
 	return config
 }
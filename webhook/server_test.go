@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -24,7 +25,10 @@ func setupWebhookTest() (*WebhookServer, *controllers.AlertReactionReconciler) {
 	_ = scheme.AddToScheme(s)
 	_ = alertreactionv1alpha1.AddToScheme(s)
 
-	fakeClient := fake.NewClientBuilder().WithScheme(s).Build()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithIndex(&alertreactionv1alpha1.AlertReaction{}, controllers.AlertNameField, controllers.AlertNameIndexer).
+		Build()
 
 	controller := &controllers.AlertReactionReconciler{
 		Client: fakeClient,
@@ -233,6 +237,180 @@ func TestWebhookServer_HandleWebhook_NonFiringAlert(t *testing.T) {
 	}
 }
 
+func TestWebhookServer_HandleWebhook_Receivers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+
+	for _, receiver := range []string{"team-a", "team-b"} {
+		alertReaction := &alertreactionv1alpha1.AlertReaction{
+			ObjectMeta: metav1.ObjectMeta{Name: receiver + "-reaction", Namespace: "default"},
+			Spec: alertreactionv1alpha1.AlertReactionSpec{
+				AlertName: "HighCPUUsage",
+				Receivers: []string{receiver},
+				Actions: []alertreactionv1alpha1.Action{
+					{Name: receiver + "-action", Image: "busybox:latest"},
+				},
+			},
+		}
+		if err := controller.Create(context.TODO(), alertReaction); err != nil {
+			t.Fatalf("Failed to create AlertReaction: %v", err)
+		}
+	}
+
+	router := webhookServer.setupRouter()
+
+	// The payload names receiver "karo", the path takes precedence
+	req, _ := http.NewRequest("POST", "/webhook/team-b", bytes.NewBuffer(firingPayload(t, "HighCPUUsage")))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var jobs batchv1.JobList
+	if err := controller.List(context.TODO(), &jobs); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 || jobs.Items[0].Labels["karo/action-name"] != "team-b-action" {
+		t.Errorf("Expected only the team-b reaction to fire, got %d jobs", len(jobs.Items))
+	}
+}
+
+func TestWebhookServer_HandleWebhook_NotificationFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "notification-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "HighCPUUsage",
+			Matchers: []alertreactionv1alpha1.AlertMatcher{
+				{Name: "notification.groupLabels.team", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "platform"},
+			},
+			Actions: []alertreactionv1alpha1.Action{
+				{
+					Name:  "notify",
+					Image: "busybox:latest",
+					Env: []alertreactionv1alpha1.EnvVar{
+						{Name: "ALERTMANAGER_URL", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+							AlertRef: &alertreactionv1alpha1.AlertFieldSelector{FieldPath: "notification.externalURL"},
+						}},
+						{Name: "GROUP_KEY", ValueFrom: &alertreactionv1alpha1.EnvVarSource{
+							AlertRef: &alertreactionv1alpha1.AlertFieldSelector{FieldPath: "notification.groupKey"},
+						}},
+					},
+				},
+			},
+		},
+	}
+	if err := controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	webhook := AlertManagerWebhook{
+		Version:     "4",
+		GroupKey:    `{}:{team="platform"}`,
+		Status:      "firing",
+		Receiver:    "karo",
+		GroupLabels: map[string]string{"team": "platform"},
+		ExternalURL: "http://alertmanager.example.com",
+		Alerts: []Alert{
+			{Status: "firing", Labels: map[string]string{"alertname": "HighCPUUsage"}},
+		},
+	}
+	jsonData, err := json.Marshal(webhook)
+	if err != nil {
+		t.Fatalf("Failed to marshal webhook: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "/webhook", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	webhookServer.setupRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var jobs batchv1.JobList
+	if err := controller.List(context.TODO(), &jobs); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Fatalf("Expected the notification matcher to match, got %d jobs", len(jobs.Items))
+	}
+
+	env := make(map[string]string)
+	for _, envVar := range jobs.Items[0].Spec.Template.Spec.Containers[0].Env {
+		env[envVar.Name] = envVar.Value
+	}
+	if env["ALERTMANAGER_URL"] != webhook.ExternalURL {
+		t.Errorf("Expected ALERTMANAGER_URL %q, got %q", webhook.ExternalURL, env["ALERTMANAGER_URL"])
+	}
+	if env["GROUP_KEY"] != webhook.GroupKey {
+		t.Errorf("Expected GROUP_KEY %q, got %q", webhook.GroupKey, env["GROUP_KEY"])
+	}
+}
+
+func TestWebhookServer_HandleWebhook_GroupMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "group-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "PodCrashLooping",
+			Mode:      alertreactionv1alpha1.ReactionModeGroup,
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "batch-restart", Image: "busybox:latest"},
+			},
+		},
+	}
+	if err := controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	webhook := AlertManagerWebhook{
+		Version:     "4",
+		GroupKey:    `{}:{alertname="PodCrashLooping"}`,
+		Status:      "firing",
+		Receiver:    "karo",
+		GroupLabels: map[string]string{"alertname": "PodCrashLooping"},
+	}
+	for _, pod := range []string{"api-1", "api-2", "api-3"} {
+		webhook.Alerts = append(webhook.Alerts, Alert{
+			Status: "firing",
+			Labels: map[string]string{"alertname": "PodCrashLooping", "pod": pod},
+		})
+	}
+	jsonData, err := json.Marshal(webhook)
+	if err != nil {
+		t.Fatalf("Failed to marshal webhook: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", "/webhook", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	webhookServer.setupRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var jobs batchv1.JobList
+	if err := controller.List(context.TODO(), &jobs); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Errorf("Expected 1 job for the notification group, got %d", len(jobs.Items))
+	}
+}
+
 func TestWebhookServer_AlertToMap(t *testing.T) {
 	webhookServer, _ := setupWebhookTest()
 
@@ -350,6 +528,53 @@ func TestWebhookServer_GetWebhookConfig(t *testing.T) {
 	}
 }
 
+func TestWebhookServer_GetWebhookConfig_SendResolved(t *testing.T) {
+	webhookServer, controller := setupWebhookTest()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "scale-back", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "HighCPUUsage",
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "scale-up", Image: "busybox:latest"},
+			},
+			OnResolved: []alertreactionv1alpha1.Action{
+				{Name: "scale-down", Image: "busybox:latest"},
+			},
+		},
+	}
+	if err := controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	config := webhookServer.GetWebhookConfig("http://example.com:9090")
+	if !contains(config, "send_resolved: true") {
+		t.Errorf("Expected config to enable send_resolved, got:\n%s", config)
+	}
+}
+
+func TestWebhookServer_GetWebhookConfig_SendResolvedForCancelOnResolve(t *testing.T) {
+	webhookServer, controller := setupWebhookTest()
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "cancel-diagnostics", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "HighCPUUsage",
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "profile", Image: "busybox:latest", CancelOnResolve: alertreactionv1alpha1.CancelPolicyDelete},
+			},
+		},
+	}
+	if err := controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	config := webhookServer.GetWebhookConfig("http://example.com:9090")
+	if !contains(config, "send_resolved: true") {
+		t.Errorf("Expected config to enable send_resolved, got:\n%s", config)
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) &&
@@ -0,0 +1,230 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Defaults for payload signature verification
+const (
+	DefaultSignatureHeader          = "X-Karo-Signature"
+	DefaultSignatureTimestampHeader = "X-Karo-Timestamp"
+	DefaultSignatureTolerance       = 5 * time.Minute
+
+	// MaxSignedPayloadSize is the largest request body read for signature verification
+	MaxSignedPayloadSize = 10 << 20
+
+	// SignatureSecretKey is the Secret key holding the default signing key.
+	// A key named "signing-key.<receiver>" overrides it for requests sent to /webhook/<receiver>.
+	SignatureSecretKey = "signing-key"
+)
+
+// Reasons reported when a request fails signature verification
+const (
+	signatureReasonMissing        = "missing_signature"
+	signatureReasonInvalid        = "invalid_signature"
+	signatureReasonStale          = "stale_timestamp"
+	signatureReasonReplayed       = "replayed_signature"
+	signatureReasonKeyUnavailable = "signing_key_unavailable"
+	signatureReasonTooLarge       = "payload_too_large"
+)
+
+// SignatureConfig configures HMAC-SHA256 verification of webhook payloads.
+// The expected signature is "sha256=" followed by hex(HMAC-SHA256(key, "<timestamp>.<raw body>")), where timestamp is the Unix time in seconds sent in TimestampHeader.
+type SignatureConfig struct {
+	// SecretRef points to the Secret holding the signing keys
+	SecretRef types.NamespacedName
+
+	// Header carrying the signature (defaults to X-Karo-Signature)
+	Header string
+
+	// TimestampHeader carrying the signing time (defaults to X-Karo-Timestamp)
+	TimestampHeader string
+
+	// Tolerance is the maximum allowed clock difference between signer and server (defaults to 5m)
+	Tolerance time.Duration
+}
+
+// WithSignatureVerification requires every alert ingestion request to carry a valid payload signature
+func WithSignatureVerification(cfg SignatureConfig) Option {
+	return func(ws *WebhookServer) {
+		if cfg.Header == "" {
+			cfg.Header = DefaultSignatureHeader
+		}
+		if cfg.TimestampHeader == "" {
+			cfg.TimestampHeader = DefaultSignatureTimestampHeader
+		}
+		if cfg.Tolerance <= 0 {
+			cfg.Tolerance = DefaultSignatureTolerance
+		}
+		ws.signature = &cfg
+		ws.seenSignatures = newReplayCache()
+	}
+}
+
+// verifySignature returns a middleware that rejects requests whose payload signature is missing,
+// invalid, outside the allowed time window or already used
+func (ws *WebhookServer) verifySignature() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		signature, reason := ws.checkSignature(c)
+		if reason == "" {
+			c.Next()
+			// Only accepted payloads use up their signature, so senders can retry failed requests
+			if c.Writer.Status() >= http.StatusMultipleChoices {
+				ws.seenSignatures.remove(signature)
+			}
+			return
+		}
+
+		log.Log.Info("Rejected webhook request with invalid signature",
+			"path", c.Request.URL.Path, "remoteAddr", c.ClientIP(), "reason", reason)
+		webhookRequestsRejected.WithLabelValues(reason).Inc()
+
+		if reason == signatureReasonTooLarge {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Payload too large"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid payload signature"})
+	}
+}
+
+// checkSignature validates the request signature and returns it, or the rejection reason.
+// The signature is recorded as used until the request fails. The request body is restored so
+// later handlers can bind it.
+func (ws *WebhookServer) checkSignature(c *gin.Context) (string, string) {
+	cfg := ws.signature
+
+	header := c.GetHeader(cfg.Header)
+	timestamp := c.GetHeader(cfg.TimestampHeader)
+	if header == "" || timestamp == "" {
+		return "", signatureReasonMissing
+	}
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return "", signatureReasonInvalid
+	}
+
+	unixSeconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", signatureReasonInvalid
+	}
+	signedAt := time.Unix(unixSeconds, 0)
+	if skew := time.Since(signedAt); skew > cfg.Tolerance || skew < -cfg.Tolerance {
+		return "", signatureReasonStale
+	}
+
+	// The body is read before it is authenticated, so its size is limited
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxSignedPayloadSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return "", signatureReasonTooLarge
+		}
+		return "", signatureReasonInvalid
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	key, err := ws.signingKey(c.Request.Context(), c.Param("receiver"))
+	if err != nil {
+		log.Log.Error(err, "Failed to load webhook signing key", "secret", cfg.SecretRef.String())
+		return "", signatureReasonKeyUnavailable
+	}
+	if len(key) == 0 {
+		log.Log.Info("Webhook signing Secret has no usable key", "secret", cfg.SecretRef.String(), "receiver", c.Param("receiver"))
+		return "", signatureReasonKeyUnavailable
+	}
+
+	provided, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(provided, computeSignature(key, timestamp, body)) {
+		return "", signatureReasonInvalid
+	}
+
+	// A valid signature may only be used once while its timestamp is still accepted. The decoded
+	// MAC is recorded, so re-encodings of the same signature are detected as replays.
+	// It is recorded before the request is handled, so concurrent deliveries cannot both be accepted.
+	recorded := hex.EncodeToString(provided)
+	if !ws.seenSignatures.add(recorded, signedAt.Add(cfg.Tolerance)) {
+		return "", signatureReasonReplayed
+	}
+
+	return recorded, ""
+}
+
+// signingKey loads the signing key for the given receiver from the configured Secret
+func (ws *WebhookServer) signingKey(ctx context.Context, receiver string) ([]byte, error) {
+	var secret corev1.Secret
+	if err := ws.controller.Get(ctx, ws.signature.SecretRef, &secret); err != nil {
+		return nil, err
+	}
+
+	if receiver != "" {
+		if key, ok := secret.Data[SignatureSecretKey+"."+receiver]; ok {
+			return key, nil
+		}
+	}
+	return secret.Data[SignatureSecretKey], nil
+}
+
+// computeSignature returns HMAC-SHA256(key, "<timestamp>.<body>")
+func computeSignature(key []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// replayCache remembers signatures until their timestamps fall out of the accepted window
+type replayCache struct {
+	mu        sync.Mutex
+	entries   map[string]time.Time
+	lastPrune time.Time
+}
+
+func newReplayCache() *replayCache {
+	return &replayCache{entries: make(map[string]time.Time)}
+}
+
+// add records a signature and reports whether it had not been seen before
+func (rc *replayCache) add(signature string, expiresAt time.Time) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(rc.lastPrune) > time.Minute {
+		for sig, expiry := range rc.entries {
+			if now.After(expiry) {
+				delete(rc.entries, sig)
+			}
+		}
+		rc.lastPrune = now
+	}
+
+	if expiry, exists := rc.entries[signature]; exists && now.Before(expiry) {
+		return false
+	}
+	rc.entries[signature] = expiresAt
+	return true
+}
+
+// remove forgets a signature, so it can be used again
+func (rc *replayCache) remove(signature string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	delete(rc.entries, signature)
+}
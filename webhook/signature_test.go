@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const signedTestPayload = `{"version":"4","receiver":"karo","alerts":[]}`
+
+func setupSignatureTest(t *testing.T) *gin.Engine {
+	return newSignatureTestServer(t).setupRouter()
+}
+
+func newSignatureTestServer(t *testing.T) *WebhookServer {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+	WithSignatureVerification(SignatureConfig{
+		SecretRef: types.NamespacedName{Namespace: "karo-system", Name: "webhook-signing"},
+	})(webhookServer)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook-signing", Namespace: "karo-system"},
+		Data: map[string][]byte{
+			SignatureSecretKey:             []byte("default-key"),
+			SignatureSecretKey + ".oncall": []byte("oncall-key"),
+		},
+	}
+	if err := controller.Create(context.TODO(), secret); err != nil {
+		t.Fatalf("Failed to create Secret: %v", err)
+	}
+
+	return webhookServer
+}
+
+func signedRequest(path, key string, signedAt time.Time, body string) *http.Request {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := hex.EncodeToString(computeSignature([]byte(key), timestamp, []byte(body)))
+
+	req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DefaultSignatureHeader, "sha256="+signature)
+	req.Header.Set(DefaultSignatureTimestampHeader, timestamp)
+	return req
+}
+
+func serve(router *gin.Engine, req *http.Request) int {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestWebhookServer_Signature(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  func() *http.Request
+		expected int
+	}{
+		{
+			name: "valid signature",
+			request: func() *http.Request {
+				return signedRequest("/webhook", "default-key", time.Now(), signedTestPayload)
+			},
+			expected: http.StatusOK,
+		},
+		{
+			name: "missing signature",
+			request: func() *http.Request {
+				req, _ := http.NewRequest("POST", "/webhook", bytes.NewBufferString(signedTestPayload))
+				return req
+			},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "signature without prefix",
+			request: func() *http.Request {
+				req := signedRequest("/webhook", "default-key", time.Now(), signedTestPayload)
+				req.Header.Set(DefaultSignatureHeader, strings.TrimPrefix(req.Header.Get(DefaultSignatureHeader), "sha256="))
+				return req
+			},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "payload too large",
+			request: func() *http.Request {
+				return signedRequest("/webhook", "default-key", time.Now(), strings.Repeat(" ", MaxSignedPayloadSize+1))
+			},
+			expected: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "wrong key",
+			request: func() *http.Request {
+				return signedRequest("/webhook", "other-key", time.Now(), signedTestPayload)
+			},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered body",
+			request: func() *http.Request {
+				req := signedRequest("/webhook", "default-key", time.Now(), signedTestPayload)
+				req.Body = http.NoBody
+				return req
+			},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "stale timestamp",
+			request: func() *http.Request {
+				return signedRequest("/webhook", "default-key", time.Now().Add(-10*time.Minute), signedTestPayload)
+			},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "per-receiver key",
+			request: func() *http.Request {
+				return signedRequest("/webhook/oncall", "oncall-key", time.Now(), signedTestPayload)
+			},
+			expected: http.StatusOK,
+		},
+		{
+			name: "default key rejected for receiver with its own key",
+			request: func() *http.Request {
+				return signedRequest("/webhook/oncall", "default-key", time.Now(), signedTestPayload)
+			},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "receiver without its own key falls back to default",
+			request: func() *http.Request {
+				return signedRequest("/webhook/platform", "default-key", time.Now(), signedTestPayload)
+			},
+			expected: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := setupSignatureTest(t)
+			if code := serve(router, test.request()); code != test.expected {
+				t.Errorf("Expected status %d, got %d", test.expected, code)
+			}
+		})
+	}
+}
+
+func TestWebhookServer_SignatureReplay(t *testing.T) {
+	router := setupSignatureTest(t)
+	signedAt := time.Now()
+
+	if code := serve(router, signedRequest("/webhook", "default-key", signedAt, signedTestPayload)); code != http.StatusOK {
+		t.Fatalf("Expected first delivery to be accepted, got %d", code)
+	}
+
+	if code := serve(router, signedRequest("/webhook", "default-key", signedAt, signedTestPayload)); code != http.StatusUnauthorized {
+		t.Errorf("Expected replayed delivery to be rejected, got %d", code)
+	}
+
+	// Hex is case-insensitive, so an upper-cased signature is the same signature
+	req := signedRequest("/webhook", "default-key", signedAt, signedTestPayload)
+	req.Header.Set(DefaultSignatureHeader, "sha256="+strings.ToUpper(strings.TrimPrefix(req.Header.Get(DefaultSignatureHeader), "sha256=")))
+	if code := serve(router, req); code != http.StatusUnauthorized {
+		t.Errorf("Expected re-encoded replayed delivery to be rejected, got %d", code)
+	}
+}
+
+func TestWebhookServer_SignatureRetryAfterRejection(t *testing.T) {
+	webhookServer := newSignatureTestServer(t)
+	WithQueue(QueueConfig{Size: 1, Workers: 1})(webhookServer)
+	router := webhookServer.setupRouter()
+	signedAt := time.Now()
+
+	// No workers are started: the first payload fills the queue and the second is rejected
+	first := `{"version":"4","receiver":"karo","alerts":[{"status":"firing","labels":{"alertname":"First"}}]}`
+	second := `{"version":"4","receiver":"karo","alerts":[{"status":"firing","labels":{"alertname":"Second"}}]}`
+	if code := serve(router, signedRequest("/webhook", "default-key", signedAt, first)); code != http.StatusAccepted {
+		t.Fatalf("Expected first delivery to be queued, got %d", code)
+	}
+	if code := serve(router, signedRequest("/webhook", "default-key", signedAt, second)); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected second delivery to be rejected while the queue is full, got %d", code)
+	}
+
+	// The retry of a rejected delivery carries the same signature and must be accepted
+	<-webhookServer.queue.items
+	if code := serve(router, signedRequest("/webhook", "default-key", signedAt, second)); code != http.StatusAccepted {
+		t.Fatalf("Expected retried delivery to be queued, got %d", code)
+	}
+
+	<-webhookServer.queue.items
+	if code := serve(router, signedRequest("/webhook", "default-key", signedAt, second)); code != http.StatusUnauthorized {
+		t.Errorf("Expected accepted delivery to be rejected as a replay, got %d", code)
+	}
+}
@@ -0,0 +1,219 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+	"github.com/dudizimber/karo/controllers"
+)
+
+// handleSourceWebhook accepts payloads for AlertSources and maps them to alerts
+func (ws *WebhookServer) handleSourceWebhook(c *gin.Context) {
+	namespace := c.Param("namespace")
+	path := strings.Trim(c.Param("path"), "/")
+
+	source, status, err := ws.findAlertSource(c, namespace, path)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var payload interface{}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid JSON: %v", err)})
+		return
+	}
+
+	alerts, err := mapSourceAlerts(&source.Spec, payload)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("Failed to map payload with AlertSource %s/%s: %v", source.Namespace, source.Name, err)})
+		return
+	}
+
+	logger := log.Log.WithValues("alertSource", source.Namespace+"/"+source.Name, "alertsCount", len(alerts))
+	logger.Info("Received webhook for AlertSource")
+
+	batch := &alertBatch{}
+	for _, alert := range alerts {
+		queued, ok := ws.newQueuedAlert(logger, alert)
+		if !ok {
+			continue
+		}
+		queued.AlertData["source"] = source.Namespace + "/" + source.Name
+		batch.Alerts = append(batch.Alerts, queued)
+	}
+
+	ws.submit(c, batch)
+}
+
+// findAlertSource looks up the AlertSource of a namespace serving the given path. Paths are
+// scoped to namespaces, so AlertSources cannot shadow the paths of other namespaces.
+// On failure it returns the HTTP status to respond with.
+func (ws *WebhookServer) findAlertSource(c *gin.Context, namespace, path string) (*alertreactionv1alpha1.AlertSource, int, error) {
+	var sourceList alertreactionv1alpha1.AlertSourceList
+	if err := ws.controller.List(c.Request.Context(), &sourceList, client.InNamespace(namespace)); err != nil {
+		log.Log.Error(err, "Failed to list AlertSources")
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to look up alert source")
+	}
+
+	var matches []*alertreactionv1alpha1.AlertSource
+	for i := range sourceList.Items {
+		if strings.Trim(sourceList.Items[i].Spec.Path, "/") == path {
+			matches = append(matches, &sourceList.Items[i])
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, http.StatusNotFound, fmt.Errorf("no AlertSource in namespace %q serves path %q", namespace, path)
+	case 1:
+		return matches[0], http.StatusOK, nil
+	default:
+		return nil, http.StatusConflict, fmt.Errorf("%d AlertSources in namespace %q serve path %q", len(matches), namespace, path)
+	}
+}
+
+// mapSourceAlerts extracts the alerts of a payload according to an AlertSource
+func mapSourceAlerts(spec *alertreactionv1alpha1.AlertSourceSpec, payload interface{}) ([]Alert, error) {
+	items := []interface{}{payload}
+	if spec.AlertsPath != "" {
+		var err error
+		items, err = evaluateJSONPath(spec.AlertsPath, payload)
+		if err != nil {
+			return nil, fmt.Errorf("alertsPath: %w", err)
+		}
+	}
+
+	mapping := &spec.Mapping
+	now := time.Now().UTC()
+	alerts := make([]Alert, 0, len(items))
+	for _, item := range items {
+		alertName, err := jsonPathString(mapping.AlertName, item)
+		if err != nil {
+			return nil, fmt.Errorf("alertName: %w", err)
+		}
+
+		labels := map[string]string{"alertname": alertName}
+		for name, expression := range mapping.Labels {
+			if labels[name], err = jsonPathString(expression, item); err != nil {
+				return nil, fmt.Errorf("label %s: %w", name, err)
+			}
+		}
+
+		annotations := make(map[string]string, len(mapping.Annotations))
+		for name, expression := range mapping.Annotations {
+			if annotations[name], err = jsonPathString(expression, item); err != nil {
+				return nil, fmt.Errorf("annotation %s: %w", name, err)
+			}
+		}
+
+		status := controllers.AlertStatusFiring
+		if mapping.Status != "" {
+			value, err := jsonPathString(mapping.Status, item)
+			if err != nil {
+				return nil, fmt.Errorf("status: %w", err)
+			}
+			if isResolvedValue(mapping.ResolvedValues, value) {
+				status = controllers.AlertStatusResolved
+			}
+		}
+
+		fingerprint := ""
+		if mapping.Fingerprint != "" {
+			if fingerprint, err = jsonPathString(mapping.Fingerprint, item); err != nil {
+				return nil, fmt.Errorf("fingerprint: %w", err)
+			}
+		}
+		if fingerprint == "" {
+			fingerprint = controllers.LabelsFingerprint(labels)
+		}
+
+		alert := Alert{
+			Status:      status,
+			Labels:      labels,
+			Annotations: annotations,
+			StartsAt:    now,
+			Fingerprint: fingerprint,
+		}
+		if status == controllers.AlertStatusResolved {
+			alert.EndsAt = now
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+func isResolvedValue(resolvedValues []string, value string) bool {
+	if len(resolvedValues) == 0 {
+		return value == controllers.AlertStatusResolved
+	}
+	for _, resolved := range resolvedValues {
+		if value == resolved {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateJSONPath returns all values selected by a JSONPath expression. The surrounding
+// braces are optional, so ".check.name" and "{.check.name}" are equivalent.
+func evaluateJSONPath(expression string, data interface{}) ([]interface{}, error) {
+	if !strings.HasPrefix(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+
+	parser := jsonpath.New("mapping").AllowMissingKeys(true)
+	if err := parser.Parse(expression); err != nil {
+		return nil, err
+	}
+
+	results, err := parser.FindResults(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	for _, result := range results {
+		for _, value := range result {
+			if value.IsValid() && value.CanInterface() {
+				values = append(values, value.Interface())
+			}
+		}
+	}
+	return values, nil
+}
+
+// jsonPathString returns the first value selected by a JSONPath expression as a string.
+// Missing values result in an empty string.
+func jsonPathString(expression string, data interface{}) (string, error) {
+	values, err := evaluateJSONPath(expression, data)
+	if err != nil || len(values) == 0 || values[0] == nil {
+		return "", err
+	}
+
+	switch value := values[0].(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	default:
+		return fmt.Sprintf("%v", value), nil
+	}
+}
@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+	"github.com/dudizimber/karo/controllers"
+)
+
+func testAlertSourceSpec() alertreactionv1alpha1.AlertSourceSpec {
+	return alertreactionv1alpha1.AlertSourceSpec{
+		Path:       "ci/builds",
+		AlertsPath: "{.events[*]}",
+		Mapping: alertreactionv1alpha1.AlertSourceMapping{
+			AlertName:      "{.check}",
+			Status:         ".state",
+			ResolvedValues: []string{"ok", "recovered"},
+			Fingerprint:    "{.id}",
+			Labels: map[string]string{
+				"pipeline": "{.pipeline.name}",
+				"attempt":  "{.attempt}",
+			},
+			Annotations: map[string]string{
+				"summary": "{.message}",
+			},
+		},
+	}
+}
+
+func TestMapSourceAlerts(t *testing.T) {
+	spec := testAlertSourceSpec()
+
+	var payload interface{}
+	if err := json.Unmarshal([]byte(`{
+		"events": [
+			{"id": "evt-1", "check": "BuildFailed", "state": "failing", "attempt": 3,
+			 "pipeline": {"name": "release"}, "message": "build failed on main"},
+			{"check": "BuildFailed", "state": "recovered", "pipeline": {"name": "nightly"}}
+		]
+	}`), &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+
+	alerts, err := mapSourceAlerts(&spec, payload)
+	if err != nil {
+		t.Fatalf("Failed to map payload: %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %d", len(alerts))
+	}
+
+	first := alerts[0]
+	if first.Status != "firing" || first.Fingerprint != "evt-1" {
+		t.Errorf("Unexpected status or fingerprint: %+v", first)
+	}
+	if first.Labels["alertname"] != "BuildFailed" || first.Labels["pipeline"] != "release" || first.Labels["attempt"] != "3" {
+		t.Errorf("Unexpected labels: %v", first.Labels)
+	}
+	if first.Annotations["summary"] != "build failed on main" {
+		t.Errorf("Unexpected annotations: %v", first.Annotations)
+	}
+
+	second := alerts[1]
+	if second.Status != "resolved" {
+		t.Errorf("Expected mapped resolved value to resolve the alert, got %s", second.Status)
+	}
+	if second.Fingerprint == "" || second.Fingerprint != controllers.LabelsFingerprint(second.Labels) {
+		t.Errorf("Expected fingerprint derived from labels, got %q", second.Fingerprint)
+	}
+}
+
+func TestMapSourceAlerts_InvalidExpression(t *testing.T) {
+	spec := testAlertSourceSpec()
+	spec.Mapping.AlertName = "{.check"
+
+	if _, err := mapSourceAlerts(&spec, map[string]interface{}{"events": []interface{}{map[string]interface{}{}}}); err == nil {
+		t.Error("Expected an error for an invalid JSONPath expression")
+	}
+}
+
+func TestWebhookServer_HandleSourceWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, controller := setupWebhookTest()
+
+	source := &alertreactionv1alpha1.AlertSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci", Namespace: "default"},
+		Spec:       testAlertSourceSpec(),
+	}
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "retry-release", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "BuildFailed",
+			Matchers: []alertreactionv1alpha1.AlertMatcher{
+				{Name: "pipeline", Operator: alertreactionv1alpha1.MatchOperatorEqual, Value: "release"},
+			},
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "retry", Image: "busybox:latest"},
+			},
+		},
+	}
+	if err := controller.Create(context.TODO(), source); err != nil {
+		t.Fatalf("Failed to create AlertSource: %v", err)
+	}
+	// An AlertSource with the same path in another namespace does not shadow it
+	otherSource := &alertreactionv1alpha1.AlertSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci", Namespace: "other"},
+		Spec:       testAlertSourceSpec(),
+	}
+	if err := controller.Create(context.TODO(), otherSource); err != nil {
+		t.Fatalf("Failed to create AlertSource: %v", err)
+	}
+	if err := controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	router := webhookServer.setupRouter()
+	payload := `{"events": [{"id": "evt-1", "check": "BuildFailed", "state": "failing", "pipeline": {"name": "release"}}]}`
+
+	req, _ := http.NewRequest("POST", "/sources/default/ci/builds", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var jobs batchv1.JobList
+	if err := controller.List(context.TODO(), &jobs); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Fatalf("Expected 1 job, got %d", len(jobs.Items))
+	}
+	if jobs.Items[0].Labels["karo/alert-fingerprint"] != "evt-1" {
+		t.Errorf("Expected mapped fingerprint on the job, got %s", jobs.Items[0].Labels["karo/alert-fingerprint"])
+	}
+
+	// Paths without an AlertSource in the namespace are not found
+	for _, path := range []string{"/sources/default/unknown", "/sources/monitoring/ci/builds"} {
+		req, _ = http.NewRequest("POST", path, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %s, got %d", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpoolConfig configures the durable spool of accepted webhook payloads
+type SpoolConfig struct {
+	// Dir is the directory entries are written to. It should be backed by persistent storage
+	// (for example a PersistentVolumeClaim) so entries survive pod restarts.
+	Dir string
+
+	// WaitForSync, if set, blocks replay until the client cache is ready to serve reads
+	WaitForSync func(ctx context.Context) bool
+}
+
+// WithSpool persists every accepted payload before it is acknowledged. Entries are removed once
+// all of their alerts were processed and replayed when the server starts.
+func WithSpool(cfg SpoolConfig) Option {
+	return func(ws *WebhookServer) {
+		ws.spool = &alertSpool{dir: cfg.Dir, waitForSync: cfg.WaitForSync}
+	}
+}
+
+// spoolEntry is a persisted payload together with its processing history
+type spoolEntry struct {
+	ID            string      `json:"id"`
+	ReceivedAt    time.Time   `json:"receivedAt"`
+	Attempts      int         `json:"attempts"`
+	LastAttemptAt *time.Time  `json:"lastAttemptAt,omitempty"`
+	LastError     string      `json:"lastError,omitempty"`
+	Batch         *alertBatch `json:"batch"`
+}
+
+// alertSpool stores one JSON file per accepted payload in a directory
+type alertSpool struct {
+	dir         string
+	waitForSync func(ctx context.Context) bool
+	mu          sync.Mutex
+}
+
+// init creates the spool directory if needed
+func (s *alertSpool) init() error {
+	return os.MkdirAll(s.dir, 0o750)
+}
+
+// add persists a new batch and assigns it a spool ID
+func (s *alertSpool) add(batch *alertBatch) error {
+	id, err := newSpoolID()
+	if err != nil {
+		return err
+	}
+
+	entry := &spoolEntry{
+		ID:         id,
+		ReceivedAt: time.Now().UTC(),
+		Batch:      batch,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.write(entry); err != nil {
+		return err
+	}
+	batch.spoolID = id
+	return nil
+}
+
+// complete records the outcome of a processing attempt. The entry is removed when no alerts
+// failed; otherwise only the failed alerts are kept for the next replay.
+func (s *alertSpool) complete(batch *alertBatch, failed []queuedAlert, processErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(failed) == 0 {
+		return s.remove(batch.spoolID)
+	}
+
+	entry, err := s.read(batch.spoolID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	entry.Attempts++
+	entry.LastAttemptAt = &now
+	if processErr != nil {
+		entry.LastError = processErr.Error()
+	}
+	entry.Batch.Alerts = failed
+
+	return s.write(entry)
+}
+
+// discard removes an entry whose payload was not accepted after all
+func (s *alertSpool) discard(batch *alertBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.remove(batch.spoolID)
+}
+
+// list returns all spooled entries ordered by the time they were received
+func (s *alertSpool) list() ([]*spoolEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	var entries []*spoolEntry
+	for _, file := range files {
+		id, ok := strings.CutSuffix(file.Name(), ".json")
+		if file.IsDir() || !ok {
+			continue
+		}
+		entry, err := s.read(id)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	// IDs start with the receive time, so they sort chronologically
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+func (s *alertSpool) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *alertSpool) read(id string) (*spoolEntry, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool entry %s: %w", id, err)
+	}
+
+	var entry spoolEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode spool entry %s: %w", id, err)
+	}
+	if entry.Batch == nil {
+		return nil, fmt.Errorf("spool entry %s has no payload", id)
+	}
+	entry.Batch.spoolID = entry.ID
+	return &entry, nil
+}
+
+// write stores an entry atomically: the data is synced to a temporary file which then replaces the entry
+func (s *alertSpool) write(entry *spoolEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode spool entry %s: %w", entry.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, entry.ID+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create spool entry %s: %w", entry.ID, err)
+	}
+	// Removing the temporary file is a no-op once it has been renamed
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write spool entry %s: %w", entry.ID, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync spool entry %s: %w", entry.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close spool entry %s: %w", entry.ID, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(entry.ID)); err != nil {
+		return fmt.Errorf("failed to commit spool entry %s: %w", entry.ID, err)
+	}
+
+	return s.syncDir()
+}
+
+func (s *alertSpool) remove(id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove spool entry %s: %w", id, err)
+	}
+	return s.syncDir()
+}
+
+// syncDir flushes directory metadata so created, renamed and removed entries survive a crash
+func (s *alertSpool) syncDir() error {
+	dir, err := os.Open(s.dir)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dir.Close() }()
+	return dir.Sync()
+}
+
+// newSpoolID returns a chronologically sortable unique entry ID
+func newSpoolID() (string, error) {
+	buffer := make([]byte, 4)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("failed to generate spool entry ID: %w", err)
+	}
+	return fmt.Sprintf("%020d-%s", time.Now().UnixNano(), hex.EncodeToString(buffer)), nil
+}
@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	alertreactionv1alpha1 "github.com/dudizimber/karo/api/v1alpha1"
+	"github.com/dudizimber/karo/controllers"
+)
+
+func testBatch(alertName string) *alertBatch {
+	return &alertBatch{
+		Alerts: []queuedAlert{
+			{
+				AlertName: alertName,
+				AlertData: map[string]interface{}{
+					"status": "firing",
+					"labels": map[string]interface{}{"alertname": alertName},
+				},
+			},
+		},
+	}
+}
+
+func TestAlertSpool_AddCompleteAndList(t *testing.T) {
+	spool := &alertSpool{dir: t.TempDir()}
+
+	first, second := testBatch("First"), testBatch("Second")
+	if err := spool.add(first); err != nil {
+		t.Fatalf("Failed to spool batch: %v", err)
+	}
+	if err := spool.add(second); err != nil {
+		t.Fatalf("Failed to spool batch: %v", err)
+	}
+
+	entries, err := spool.list()
+	if err != nil {
+		t.Fatalf("Failed to list spool: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Batch.Alerts[0].AlertName != "First" {
+		t.Fatalf("Expected 2 entries in receive order, got %+v", entries)
+	}
+
+	// A failed attempt keeps the entry with its error
+	if err := spool.complete(second, second.Alerts, errors.New("api server unavailable")); err != nil {
+		t.Fatalf("Failed to record failed attempt: %v", err)
+	}
+	// A successful attempt removes the entry
+	if err := spool.complete(first, nil, nil); err != nil {
+		t.Fatalf("Failed to record successful attempt: %v", err)
+	}
+
+	entries, err = spool.list()
+	if err != nil {
+		t.Fatalf("Failed to list spool: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 remaining entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Attempts != 1 || entry.LastError != "api server unavailable" || entry.LastAttemptAt == nil {
+		t.Errorf("Unexpected attempt bookkeeping: %+v", entry)
+	}
+	if entry.Batch.Alerts[0].AlertData["status"] != "firing" {
+		t.Errorf("Expected alert data to survive a round trip, got %+v", entry.Batch.Alerts[0].AlertData)
+	}
+}
+
+func TestWebhookServer_ReplaySpool(t *testing.T) {
+	webhookServer, controller := setupWebhookTest()
+	WithSpool(SpoolConfig{Dir: t.TempDir()})(webhookServer)
+
+	alertReaction := &alertreactionv1alpha1.AlertReaction{
+		ObjectMeta: metav1.ObjectMeta{Name: "spooled-reaction", Namespace: "default"},
+		Spec: alertreactionv1alpha1.AlertReactionSpec{
+			AlertName: "DiskFull",
+			Actions: []alertreactionv1alpha1.Action{
+				{Name: "cleanup", Image: "busybox:latest"},
+			},
+		},
+	}
+	if err := controller.Create(context.TODO(), alertReaction); err != nil {
+		t.Fatalf("Failed to create AlertReaction: %v", err)
+	}
+
+	// Simulate a payload left behind by a previous run
+	if err := webhookServer.spool.add(testBatch("DiskFull")); err != nil {
+		t.Fatalf("Failed to spool batch: %v", err)
+	}
+	leftovers, err := webhookServer.spool.list()
+	if err != nil {
+		t.Fatalf("Failed to list spool: %v", err)
+	}
+
+	// A payload received by this run is spooled and queued before the replay starts
+	current := testBatch("DiskFull")
+	if err := webhookServer.spool.add(current); err != nil {
+		t.Fatalf("Failed to spool batch: %v", err)
+	}
+
+	webhookServer.replaySpool(context.TODO(), leftovers)
+
+	var jobs batchv1.JobList
+	if err := controller.List(context.TODO(), &jobs); err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Errorf("Expected 1 job from the replayed payload, got %d", len(jobs.Items))
+	}
+
+	// Only the leftover payload is replayed, the current one is left to the queue
+	entries, err := webhookServer.spool.list()
+	if err != nil {
+		t.Fatalf("Failed to list spool: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != current.spoolID {
+		t.Errorf("Expected only the current entry to remain, got %+v", entries)
+	}
+}
+
+func TestWebhookServer_SpoolKeepsFailedAlerts(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = alertreactionv1alpha1.AddToScheme(s)
+
+	failingClient := fake.NewClientBuilder().WithScheme(s).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			return errors.New("api server unavailable")
+		},
+	}).Build()
+
+	webhookServer := NewWebhookServer(&controllers.AlertReactionReconciler{Client: failingClient, Scheme: s}, "9090",
+		WithSpool(SpoolConfig{Dir: t.TempDir()}))
+
+	batch := testBatch("DiskFull")
+	if err := webhookServer.spool.add(batch); err != nil {
+		t.Fatalf("Failed to spool batch: %v", err)
+	}
+	webhookServer.handleBatch(context.TODO(), batch)
+
+	entries, err := webhookServer.spool.list()
+	if err != nil {
+		t.Fatalf("Failed to list spool: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected the failed entry to remain spooled, got %d entries", len(entries))
+	}
+	if entries[0].Attempts != 1 || entries[0].LastError == "" {
+		t.Errorf("Expected attempt count and last error to be recorded, got %+v", entries[0])
+	}
+}
+
+func TestWebhookServer_SpoolDiscardsRejectedPayload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookServer, _ := setupWebhookTest()
+	WithSpool(SpoolConfig{Dir: t.TempDir()})(webhookServer)
+	WithQueue(QueueConfig{Size: 1, Workers: 1})(webhookServer)
+	router := webhookServer.setupRouter()
+
+	// No workers are started: the first payload is queued, the second is rejected
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("POST", "/webhook", bytes.NewReader(firingPayload(t, "HighCPUUsage")))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, _ := http.NewRequest("GET", "/spool", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 from spool listing, got %d", w.Code)
+	}
+
+	entries, err := webhookServer.spool.list()
+	if err != nil {
+		t.Fatalf("Failed to list spool: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the accepted payload to be spooled, got %d entries", len(entries))
+	}
+}
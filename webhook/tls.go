@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// TLSConfig configures HTTPS serving of the webhook endpoints
+type TLSConfig struct {
+	// CertFile is the path to the PEM encoded serving certificate
+	CertFile string
+
+	// KeyFile is the path to the PEM encoded private key of the serving certificate
+	KeyFile string
+
+	// ClientCAFile is the path to a PEM encoded CA bundle. When set, clients must present
+	// a certificate signed by one of these CAs (mutual TLS).
+	ClientCAFile string
+}
+
+// WithTLS serves the webhook endpoints over HTTPS. Certificate, key and client CA files are
+// watched and reloaded when they change, so rotated certificates are picked up without a restart.
+func WithTLS(cfg TLSConfig) Option {
+	return func(ws *WebhookServer) {
+		ws.tls = &cfg
+	}
+}
+
+// buildTLSConfig loads the configured certificates and starts watching them for changes
+// until ctx is cancelled
+func (ws *WebhookServer) buildTLSConfig(ctx context.Context) (*tls.Config, error) {
+	logger := log.FromContext(ctx)
+
+	watcher, err := certwatcher.New(ws.tls.CertFile, ws.tls.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook serving certificate: %w", err)
+	}
+	ws.certWatcher = watcher
+
+	go func() {
+		if err := watcher.Start(ctx); err != nil {
+			logger.Error(err, "Webhook certificate watcher stopped")
+		}
+	}()
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: watcher.GetCertificate,
+	}
+
+	if ws.tls.ClientCAFile != "" {
+		clientCAs := &clientCAWatcher{path: ws.tls.ClientCAFile}
+		if _, err := clientCAs.load(); err != nil {
+			return nil, fmt.Errorf("failed to load webhook client CA bundle: %w", err)
+		}
+
+		// Resolve the client CA pool per handshake so an updated bundle applies to new connections
+		tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			pool, err := clientCAs.load()
+			if err != nil {
+				logger.Error(err, "Failed to reload webhook client CA bundle, using previous bundle")
+			}
+			cfg := tlsConfig.Clone()
+			cfg.GetConfigForClient = nil
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			cfg.ClientCAs = pool
+			return cfg, nil
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// clientCAWatcher caches a CA bundle and re-reads it whenever the file's modification time changes
+type clientCAWatcher struct {
+	path string
+
+	mu      sync.Mutex
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+// load returns the current CA pool, reloading it from disk if the file changed.
+// On error the previously loaded pool is returned alongside the error.
+func (w *clientCAWatcher) load() (*x509.CertPool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return w.pool, err
+	}
+	if w.pool != nil && info.ModTime().Equal(w.modTime) {
+		return w.pool, nil
+	}
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return w.pool, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return w.pool, fmt.Errorf("no certificates found in %s", w.path)
+	}
+
+	w.pool = pool
+	w.modTime = info.ModTime()
+	return pool, nil
+}
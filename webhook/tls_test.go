@@ -0,0 +1,220 @@
+package webhook
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM encoded certificate and key signed by the CA
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// startTLSServer serves the webhook router with the server's TLS configuration and returns its address
+func startTLSServer(t *testing.T, ws *WebhookServer) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	tlsConfig, err := ws.buildTLSConfig(ctx)
+	if err != nil {
+		t.Fatalf("buildTLSConfig failed: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := &http.Server{Handler: ws.setupRouter(), ReadHeaderTimeout: time.Second}
+	go func() { _ = server.Serve(tls.NewListener(listener, tlsConfig)) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	return "https://" + listener.Addr().String()
+}
+
+func tlsClient(roots []byte, certPEM, keyPEM []byte) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(roots)
+	cfg := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if certPEM != nil {
+		cert, _ := tls.X509KeyPair(certPEM, keyPEM)
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{TLSClientConfig: cfg}}
+}
+
+func TestWebhookServer_TLS(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := newTestCA(t, "server-ca")
+	certPEM, keyPEM := serverCA.issue(t, "karo", x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(dir, "tls.crt"), certPEM)
+	writeFile(t, filepath.Join(dir, "tls.key"), keyPEM)
+
+	webhookServer, _ := setupWebhookTest()
+	WithTLS(TLSConfig{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key")})(webhookServer)
+	addr := startTLSServer(t, webhookServer)
+
+	resp, err := tlsClient(serverCA.pem, nil, nil).Get(addr + "/health")
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	// Rotate the serving certificate to one issued by a new CA
+	rotatedCA := newTestCA(t, "rotated-ca")
+	certPEM, keyPEM = rotatedCA.issue(t, "karo", x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(dir, "tls.crt"), certPEM)
+	writeFile(t, filepath.Join(dir, "tls.key"), keyPEM)
+	if err := webhookServer.certWatcher.ReadCertificate(); err != nil {
+		t.Fatalf("Failed to reload certificate: %v", err)
+	}
+
+	resp, err = tlsClient(rotatedCA.pem, nil, nil).Get(addr + "/health")
+	if err != nil {
+		t.Fatalf("HTTPS request with rotated certificate failed: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestWebhookServer_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := newTestCA(t, "server-ca")
+	clientCA := newTestCA(t, "client-ca")
+	otherCA := newTestCA(t, "other-ca")
+
+	certPEM, keyPEM := serverCA.issue(t, "karo", x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(dir, "tls.crt"), certPEM)
+	writeFile(t, filepath.Join(dir, "tls.key"), keyPEM)
+	writeFile(t, filepath.Join(dir, "client-ca.crt"), clientCA.pem)
+
+	webhookServer, _ := setupWebhookTest()
+	WithTLS(TLSConfig{
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "client-ca.crt"),
+	})(webhookServer)
+	addr := startTLSServer(t, webhookServer)
+
+	alertmanagerCert, alertmanagerKey := clientCA.issue(t, "alertmanager", x509.ExtKeyUsageClientAuth)
+	otherCert, otherKey := otherCA.issue(t, "intruder", x509.ExtKeyUsageClientAuth)
+
+	if _, err := tlsClient(serverCA.pem, nil, nil).Get(addr + "/health"); err == nil {
+		t.Error("Expected request without client certificate to fail")
+	}
+
+	if _, err := tlsClient(serverCA.pem, otherCert, otherKey).Get(addr + "/health"); err == nil {
+		t.Error("Expected request with untrusted client certificate to fail")
+	}
+
+	resp, err := tlsClient(serverCA.pem, alertmanagerCert, alertmanagerKey).Get(addr + "/health")
+	if err != nil {
+		t.Fatalf("Expected request with trusted client certificate to succeed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	// Rotate the client CA bundle; new connections must use it
+	caPath := filepath.Join(dir, "client-ca.crt")
+	writeFile(t, caPath, otherCA.pem)
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(caPath, future, future); err != nil {
+		t.Fatalf("Failed to update CA bundle modification time: %v", err)
+	}
+
+	resp, err = tlsClient(serverCA.pem, otherCert, otherKey).Get(addr + "/health")
+	if err != nil {
+		t.Fatalf("Expected client certificate from rotated CA to be accepted: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestWebhookServer_GetWebhookURLWithTLS(t *testing.T) {
+	webhookServer, _ := setupWebhookTest()
+	WithTLS(TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key"})(webhookServer)
+
+	if url := webhookServer.GetWebhookURL(""); url != "https://localhost:9090/webhook" {
+		t.Errorf("Expected https webhook URL, got %s", url)
+	}
+	if config := webhookServer.GetWebhookConfig(""); !contains(config, "tls_config:") {
+		t.Error("Expected AlertManager config to contain tls_config")
+	}
+}